@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// Scopes of API tokens. Reading is only for methods that change nothing.
+const (
+	ScopeRead  = "legi"
+	ScopeWrite = "skribi"
+)
+
+// maxAPITokenTTL is as long as an API token can last.
+const maxAPITokenTTL = 366 * 24 * time.Hour
+
+// checkScopes checks scopes that someone asked for, returning them sorted and without repeats.
+func checkScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: mankas rajtoj", lib.ErrHTTPBadRequest)
+	}
+
+	out := make([]string, 0, len(scopes))
+
+	for _, s := range scopes {
+		if s != ScopeRead && s != ScopeWrite {
+			return nil, fmt.Errorf("%w: nekonata rajto %q", lib.ErrHTTPBadRequest, s)
+		}
+
+		out = append(out, s)
+	}
+
+	slices.Sort(out)
+
+	return slices.Compact(out), nil
+}
+
+// checkTokenExpiry makes sure that a new token ends some time soon, but not already.
+func checkTokenExpiry(expires *time.Time, now time.Time) error {
+	if expires == nil {
+		return fmt.Errorf("%w: mankas limdato", lib.ErrHTTPBadRequest)
+	}
+
+	if !expires.After(now) {
+		return fmt.Errorf("%w: limdato jam pasis", lib.ErrHTTPBadRequest)
+	}
+
+	if expires.Sub(now) > maxAPITokenTTL {
+		return fmt.Errorf("%w: limdato tro malproksima", lib.ErrHTTPBadRequest)
+	}
+
+	return nil
+}
+
+// tokenAllows says whether a token with some scopes may be used for a request method.
+func tokenAllows(scopes []string, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return slices.Contains(scopes, ScopeRead) || slices.Contains(scopes, ScopeWrite)
+	default:
+		return slices.Contains(scopes, ScopeWrite)
+	}
+}
+
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return nil
+	}
+
+	return strings.Split(scopes, ",")
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestCheckScopes(t *testing.T) {
+	scopes, err := checkScopes([]string{"skribi", "legi", "skribi"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"legi", "skribi"}, scopes)
+
+	_, err = checkScopes(nil)
+	assert.ErrorIs(t, err, lib.ErrHTTPBadRequest)
+
+	_, err = checkScopes([]string{"ĉio"})
+	assert.ErrorIs(t, err, lib.ErrHTTPBadRequest)
+}
+
+func TestCheckTokenExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ok := now.Add(30 * 24 * time.Hour)
+	past := now.Add(-time.Minute)
+	far := now.Add(2 * 366 * 24 * time.Hour)
+
+	assert.NoError(t, checkTokenExpiry(&ok, now))
+	assert.ErrorIs(t, checkTokenExpiry(nil, now), lib.ErrHTTPBadRequest)
+	assert.ErrorIs(t, checkTokenExpiry(&past, now), lib.ErrHTTPBadRequest)
+	assert.ErrorIs(t, checkTokenExpiry(&far, now), lib.ErrHTTPBadRequest)
+}
+
+func TestTokenAllows(t *testing.T) {
+	read := []string{ScopeRead}
+	write := []string{ScopeWrite}
+
+	assert.True(t, tokenAllows(read, "GET"))
+	assert.False(t, tokenAllows(read, "POST"))
+	assert.False(t, tokenAllows(read, "DELETE"))
+
+	assert.True(t, tokenAllows(write, "GET"))
+	assert.True(t, tokenAllows(write, "PATCH"))
+
+	assert.False(t, tokenAllows(nil, "GET"))
+	assert.Equal(t, []string{"legi", "skribi"}, splitScopes("legi,skribi"))
+}
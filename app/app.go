@@ -1,33 +1,82 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/config"
 	"github.com/undeconstructed/skribserv/lib"
 )
 
+const sessionSweepInterval = 10 * time.Minute
+
 func makeRandomID(prefix string, length int) DBID {
 	return DBID(lib.MakeRandomID(prefix, length))
 }
 
+func dbidsToStrings(ids []DBID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, string(id))
+	}
+
+	return out
+}
+
 type App struct {
 	*back
 	*front
 }
 
-func New(db rel.Repository, log *slog.Logger) (*App, error) {
+func New(db rel.Repository, cfg *config.Config, log *slog.Logger) (*App, error) {
 	back := &back{
 		db:  db,
 		log: lib.SubLog(log),
 	}
 
+	cookie, err := makeSessionCookie(cfg.Cookie)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := lib.NewLocalBlobStore(cfg.Files.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("file store: %w", err)
+	}
+
+	var mail lib.MailSender = lib.NewLogMailSender(lib.SubLog(log))
+	if cfg.Mail.Addr != "" {
+		mail = lib.NewSMTPSender(cfg.Mail.Addr, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From)
+	}
+
+	var oidc *lib.OIDCProvider
+	if cfg.OIDC.Issuer != "" {
+		oidc = lib.NewOIDCProvider(cfg.OIDC.Issuer, cfg.OIDC.ClientID, cfg.OIDC.ClientSecret, cfg.OIDC.RedirectURL, nil)
+	}
+
 	front := &front{
-		back:  back,
-		ident: NewAuthenticator(),
-		log:   lib.SubLog(log),
+		back:           back,
+		ident:          NewAuthenticator(NewDBSessionStore(db), cfg.SessionTTL, lib.SubLog(log)),
+		cookie:         cookie,
+		store:          store,
+		files:          cfg.Files,
+		mail:           mail,
+		publicURL:      cfg.PublicURL,
+		registration:   cfg.Registration,
+		resetTTL:       cfg.ResetTTL,
+		twoFactor:      cfg.TwoFactor,
+		oidc:           oidc,
+		oidcConfig:     cfg.OIDC,
+		limits:         newLoginLimits(cfg.LoginLimits),
+		resetLimits:    newLoginLimits(cfg.LoginLimits),
+		registerLimits: newLoginLimits(cfg.LoginLimits),
+		log:            lib.SubLog(log),
 	}
 
 	app := &App{
@@ -37,3 +86,34 @@ func New(db rel.Repository, log *slog.Logger) (*App, error) {
 
 	return app, nil
 }
+
+// makeSessionCookie makes the template for all session cookies.
+func makeSessionCookie(cfg config.CookieConfig) (http.Cookie, error) {
+	cookie := http.Cookie{
+		Name:     "Seanco",
+		Path:     "/",
+		Domain:   cfg.Domain,
+		Secure:   cfg.Secure,
+		HttpOnly: true,
+	}
+
+	switch cfg.SameSite {
+	case "strict":
+		cookie.SameSite = http.SameSiteStrictMode
+	case "lax":
+		cookie.SameSite = http.SameSiteLaxMode
+	case "none":
+		cookie.SameSite = http.SameSiteNoneMode
+	default:
+		return http.Cookie{}, fmt.Errorf("bad cookie same_site: %q", cfg.SameSite)
+	}
+
+	return cookie, nil
+}
+
+// Run does background work, until the context ends.
+func (app *App) Run(ctx context.Context) {
+	go app.back.sweep(ctx, sessionSweepInterval)
+
+	app.front.ident.sweep(ctx, sessionSweepInterval)
+}
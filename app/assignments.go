@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// maxHomeworkText is the longest homework text, in bytes, which also keeps diffs of revisions
+// within reason.
+const maxHomeworkText = 100_000
+
+// checkHomeworkText checks homework text that is about to be saved.
+func checkHomeworkText(text string) error {
+	if text == "" {
+		return fmt.Errorf("%w: mankas teksto", lib.ErrHTTPBadRequest)
+	}
+
+	if len(text) > maxHomeworkText {
+		return fmt.Errorf("%w: teksto tro longa", lib.ErrHTTPTooLarge)
+	}
+
+	return nil
+}
+
+// checkSubmission checks homework text against its assignment. The deadline only counts for
+// the first submission, because later ones are responses to corrections.
+func checkSubmission(assignment Assignment, text string, now time.Time, first bool) (late bool, err error) {
+	if assignment.MaxWords > 0 {
+		if n := lib.CountWords(text); n > assignment.MaxWords {
+			return false, fmt.Errorf("%w: %d vortoj, sed maksimume %d", lib.ErrHTTPBadRequest, n, assignment.MaxWords)
+		}
+	}
+
+	if !first || assignment.DueAt == nil || !now.After(*assignment.DueAt) {
+		return false, nil
+	}
+
+	if !assignment.AllowLate {
+		return false, fmt.Errorf("%w: limdato pasis", lib.ErrHTTPConflict)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestCheckSubmission(t *testing.T) {
+	due := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, after := due.Add(-time.Hour), due.Add(time.Hour)
+
+	assignment := Assignment{DueAt: &due, MaxWords: 3}
+
+	late, err := checkSubmission(assignment, "unu du tri", before, true)
+	assert.NoError(t, err)
+	assert.False(t, late)
+
+	_, err = checkSubmission(assignment, "unu du tri kvar", before, true)
+	assert.ErrorIs(t, err, lib.ErrHTTPBadRequest)
+
+	_, err = checkSubmission(assignment, "unu", after, true)
+	assert.ErrorIs(t, err, lib.ErrHTTPConflict)
+
+	late, err = checkSubmission(assignment, "unu", after, false)
+	assert.NoError(t, err)
+	assert.False(t, late)
+
+	assignment.AllowLate = true
+
+	late, err = checkSubmission(assignment, "unu", after, true)
+	assert.NoError(t, err)
+	assert.True(t, late)
+}
+
+func TestCheckHomeworkText(t *testing.T) {
+	assert.NoError(t, checkHomeworkText("saluton"))
+	assert.ErrorIs(t, checkHomeworkText(""), lib.ErrHTTPBadRequest)
+	assert.ErrorIs(t, checkHomeworkText(strings.Repeat("a", maxHomeworkText+1)), lib.ErrHTTPTooLarge)
+}
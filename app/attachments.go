@@ -0,0 +1,38 @@
+package app
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// checkUploadType finds the type of a file from its first bytes, never trusting what the
+// client says, and makes sure that it is allowed.
+func checkUploadType(head []byte, allowed []string) (string, error) {
+	sniffed, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", fmt.Errorf("%w: nekonata tipo", lib.ErrHTTPUnsupportedMedia)
+	}
+
+	if !slices.Contains(allowed, sniffed) {
+		return "", fmt.Errorf("%w: tipo %s ne permesata", lib.ErrHTTPUnsupportedMedia, sniffed)
+	}
+
+	return sniffed, nil
+}
+
+// cleanFileName keeps only the last part of a name that came from the client.
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	if name == "." || name == "/" || name == "" {
+		return "dosiero"
+	}
+
+	return name
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestCheckUploadType(t *testing.T) {
+	allowed := []string{"application/pdf", "text/plain"}
+
+	typ, err := checkUploadType([]byte("%PDF-1.7\n"), allowed)
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", typ)
+
+	typ, err = checkUploadType([]byte("Saluton!"), allowed)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", typ)
+
+	_, err = checkUploadType([]byte("<html><script>"), allowed)
+	assert.ErrorIs(t, err, lib.ErrHTTPUnsupportedMedia)
+}
+
+func TestCleanFileName(t *testing.T) {
+	assert.Equal(t, "skizo.pdf", cleanFileName("../../skizo.pdf"))
+	assert.Equal(t, "skizo.pdf", cleanFileName(`C:\Users\ana\skizo.pdf`))
+	assert.Equal(t, "dosiero", cleanFileName(""))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/go-rel/rel"
 	"github.com/go-rel/rel/where"
@@ -12,22 +14,73 @@ import (
 
 const adminEmail = "admin@admin"
 
+// dummyPasswordHash is checked against when there is no user, to keep timing even.
+var dummyPasswordHash, _ = lib.HashPassword("dummy")
+
 type back struct {
 	db  rel.Repository
 	log lib.MakeContextLogger
 }
 
+// sweep deletes expired rows every so often, until the context ends, as the Authenticator
+// does for sessions.
+func (a *back) sweep(ctx context.Context, every time.Duration) {
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			sweeps := []struct {
+				name string
+				f    func(context.Context, time.Time) (int, error)
+			}{
+				{"oidc logins", a.deleteExpiredOIDCLogins},
+				{"user tokens", a.deleteExpiredUserTokens},
+			}
+
+			for _, sw := range sweeps {
+				n, err := sw.f(ctx, time.Now())
+				if err != nil {
+					a.log(ctx).Error("sweep "+sw.name, "err", err)
+					continue
+				}
+
+				if n > 0 {
+					a.log(ctx).Debug("swept "+sw.name, "count", n)
+				}
+			}
+		}
+	}
+}
+
+// asMutators is because rel wants []Mutator, and Go will not convert a []Mutate.
+func asMutators(mutates []rel.Mutate) []rel.Mutator {
+	out := make([]rel.Mutator, 0, len(mutates))
+	for _, m := range mutates {
+		out = append(out, m)
+	}
+
+	return out
+}
+
 func (a *back) EnsureAdmin(ctx context.Context, password string) (User, error) {
 	user := &User{}
 
-	err := a.db.Find(ctx, user, where.Eq("email", adminEmail))
+	// unscoped, because a deleted admin is brought back
+	err := a.db.Find(ctx, user, where.Eq("email", adminEmail), rel.Unscoped(true))
 	if err != nil {
 		if errors.Is(err, rel.ErrNotFound) {
+			now := time.Now()
+
 			user1, err := a.putUser(ctx, User{
-				Name:     "Admin User",
-				Email:    adminEmail,
-				Password: password,
-				Admin:    true,
+				Name:       "Admin User",
+				Email:      adminEmail,
+				Password:   password,
+				Admin:      true,
+				VerifiedAt: &now,
 			})
 			if err != nil {
 				return User{}, err
@@ -41,11 +94,16 @@ func (a *back) EnsureAdmin(ctx context.Context, password string) (User, error) {
 		return User{}, fmt.Errorf("db (read): %w", err)
 	}
 
-	if user.Password == password {
+	if ok, rehash := lib.CheckPassword(user.Password, password); ok && !rehash && user.DeletedAt == nil {
 		return *user, nil
 	}
 
-	err = a.db.Update(ctx, user, rel.Set("password", password))
+	hash, err := lib.HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	err = a.db.Update(ctx, user, rel.Set("password", hash), rel.Set("deleted_at", nil), rel.Set("updated_at", time.Now()), rel.Unscoped(true))
 	if err != nil {
 		return User{}, fmt.Errorf("db (write): %w", err)
 	}
@@ -66,18 +124,65 @@ func (a *back) listUsers(ctx context.Context) ([]User, error) {
 	return out, nil
 }
 
+// putUser makes a new user, with a plain password that is always hashed, even if it looks like
+// a hash already, since it may have come from anyone. Old stored passwords are upgraded when
+// checked, not here.
 func (a *back) putUser(ctx context.Context, user0 User) (User, error) {
 	if user0.ID == "" {
 		user0.ID = makeRandomID("u", 5)
 	}
 
-	if err := a.db.Insert(ctx, &user0); err != nil {
+	hash, err := lib.HashPassword(user0.Password)
+	if err != nil {
 		return User{}, err
 	}
 
+	user0.Password = hash
+
+	if err := a.db.Insert(ctx, &user0); err != nil {
+		if errors.Is(err, rel.ConstraintError{Type: rel.UniqueConstraint}) {
+			return User{}, fmt.Errorf("%w: retpoŝto jam uzata", lib.ErrHTTPConflict)
+		}
+
+		return User{}, fmt.Errorf("db (write): %w", err)
+	}
+
 	return user0, nil
 }
 
+// updateUser changes some fields of a user, keeping updated_at up to date.
+func (a *back) updateUser(ctx context.Context, user User, mutates ...rel.Mutate) (User, error) {
+	mutates = append(mutates, rel.Set("updated_at", time.Now()))
+
+	if err := a.db.Update(ctx, &user, asMutators(mutates)...); err != nil {
+		if errors.Is(err, rel.ConstraintError{Type: rel.UniqueConstraint}) {
+			return User{}, fmt.Errorf("%w: retpoŝto jam uzata", lib.ErrHTTPConflict)
+		}
+
+		return User{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return user, nil
+}
+
+func (a *back) setUserPassword(ctx context.Context, user User, password string) (User, error) {
+	hash, err := lib.HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	return a.updateUser(ctx, user, rel.Set("password", hash))
+}
+
+// deleteUser soft deletes a user, so that they cannot log in, but their data remains.
+func (a *back) deleteUser(ctx context.Context, user User) error {
+	if err := a.db.Delete(ctx, &user); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
 func (a *back) getUser(ctx context.Context, id DBID) (User, error) {
 	user := &User{}
 
@@ -93,21 +198,81 @@ func (a *back) getUser(ctx context.Context, id DBID) (User, error) {
 	return *user, nil
 }
 
+// getUserByLogin finds a user by their email, and checks their password. A wrong password
+// looks the same as an unknown email, i.e. [rel.ErrNotFound]. Users who have not confirmed
+// their email get [ErrUnverified], and locked accounts get [ErrLocked], whatever the password.
 func (a *back) getUserByLogin(ctx context.Context, email, password string) (User, error) {
 	user := &User{}
 
-	err := a.db.Find(ctx, user, where.Eq("email", email), where.Eq("password", password))
+	err := a.db.Find(ctx, user, where.Eq("email", email))
 	if err != nil {
 		if errors.Is(err, rel.ErrNotFound) {
+			// burn about the same time as a real check, so as not to reveal which emails exist
+			lib.CheckPassword(dummyPasswordHash, password)
 			return User{}, err
 		}
 
 		return User{}, fmt.Errorf("db (read): %w", err)
 	}
 
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		lib.CheckPassword(dummyPasswordHash, password)
+		return User{}, ErrLocked
+	}
+
+	ok, rehash := lib.CheckPassword(user.Password, password)
+	if !ok {
+		return User{}, rel.ErrNotFound
+	}
+
+	if user.VerifiedAt == nil {
+		return User{}, ErrUnverified
+	}
+
+	// with TOTP, the failures are only forgotten once the code is right too
+	if user.TOTPEnabledAt == nil && (user.FailedLogins > 0 || user.LockedUntil != nil) {
+		*user, err = a.updateUser(ctx, *user, rel.Set("failed_logins", 0), rel.Set("locked_until", nil))
+		if err != nil {
+			return User{}, err
+		}
+	}
+
+	if rehash {
+		user1, err := a.setUserPassword(ctx, *user, password)
+		if err != nil {
+			return User{}, err
+		}
+
+		a.log(ctx).Info("rehashed password", "user", user.ID)
+
+		return user1, nil
+	}
+
 	return *user, nil
 }
 
+// failLogin counts a wrong password for whoever has an email, if anyone, and locks their
+// account once there have been enough in a row. It says whether it locked the account.
+func (a *back) failLogin(ctx context.Context, email string, lockAfter int, lockFor time.Duration) (bool, error) {
+	_, err := a.db.UpdateAny(ctx, rel.From("users").Where(where.Eq("email", email)), rel.Inc("failed_logins"))
+	if err != nil {
+		return false, fmt.Errorf("db (write): %w", err)
+	}
+
+	n, err := a.db.UpdateAny(ctx, rel.From("users").Where(where.Eq("email", email), where.Gte("failed_logins", lockAfter)),
+		rel.Set("failed_logins", 0), rel.Set("locked_until", time.Now().Add(lockFor)))
+	if err != nil {
+		return false, fmt.Errorf("db (write): %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// unlockUser lets a user log in again straight away, after being locked.
+func (a *back) unlockUser(ctx context.Context, user User) (User, error) {
+	return a.updateUser(ctx, user, rel.Set("failed_logins", 0), rel.Set("locked_until", nil))
+}
+
 func (a *back) getUserByEmail(ctx context.Context, email string) (User, error) {
 	user := &User{}
 
@@ -123,10 +288,15 @@ func (a *back) getUserByEmail(ctx context.Context, email string) (User, error) {
 	return *user, nil
 }
 
-func (a *back) listCourses(ctx context.Context) ([]Course, error) {
+func (a *back) listCourses(ctx context.Context, archived bool) ([]Course, error) {
 	var out []Course
 
-	err := a.db.FindAll(ctx, &out)
+	var queriers []rel.Querier
+	if !archived {
+		queriers = append(queriers, where.Nil("archived_at"))
+	}
+
+	err := a.db.FindAll(ctx, &out, queriers...)
 	if err != nil {
 		return nil, fmt.Errorf("db (read): %w", err)
 	}
@@ -165,24 +335,307 @@ func (a *back) getCourse(ctx context.Context, id DBID) (Course, error) {
 	return *course, nil
 }
 
-func (a *back) addUserToCourse(ctx context.Context, user, course DBID) (Learner, error) {
+func (a *back) updateCourse(ctx context.Context, course Course, mutates ...rel.Mutate) (Course, error) {
+	if err := a.db.Update(ctx, &course, asMutators(mutates)...); err != nil {
+		return Course{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return course, nil
+}
+
+// deleteCourse really deletes a course, and by cascade all of its lessons, learners and homework.
+func (a *back) deleteCourse(ctx context.Context, course Course) error {
+	if err := a.db.Delete(ctx, &course); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// getCourseRoles finds out all the roles that a user has in a course. Learners only count
+// while they are active, and not waiting.
+func (a *back) getCourseRoles(ctx context.Context, user *User, course Course) ([]Role, error) {
+	teachers, err := a.db.Count(ctx, "teachers", where.Eq("user", user.ID), where.Eq("course", course.ID))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	observers, err := a.db.Count(ctx, "observers", where.Eq("user", user.ID), where.Eq("course", course.ID))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	learners, err := a.db.Count(ctx, "learners", where.Eq("user", user.ID), where.Eq("course", course.ID),
+		where.Eq("status", LearnerActive), where.Nil("waiting_since"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return courseRoles(user, course, teachers > 0, observers > 0, learners > 0), nil
+}
+
+// addUserToCourse makes a user a learner of a course. If the course is already full, they
+// go on the waitlist instead. The course is locked, so that two users can't both take the
+// last place. A user who dropped out before comes back as the same learner, with their
+// old homework, but when joining by themselves only if they were not taken out by someone
+// else.
+func (a *back) addUserToCourse(ctx context.Context, user, course DBID, self bool) (Learner, error) {
+	now := time.Now()
+
 	learner := &Learner{
-		ID:       makeRandomID("l", 5),
-		UserID:   user,
-		CourseID: course,
+		ID:         makeRandomID("l", 5),
+		UserID:     user,
+		CourseID:   course,
+		Status:     LearnerActive,
+		EnrolledAt: &now,
+	}
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		c, enrolled, err := a.lockCourseForLearners(ctx, course)
+		if err != nil {
+			return err
+		}
+
+		if placesLeft(c, enrolled) == 0 {
+			learner.WaitingSince = &now
+		}
+
+		old := &Learner{}
+
+		err = a.db.Find(ctx, old, where.Eq("user", user), where.Eq("course", course))
+		if err == nil {
+			if old.Status != LearnerDropped {
+				return fmt.Errorf("%w: jam lernanto", lib.ErrHTTPConflict)
+			}
+
+			if self {
+				if err := checkRejoin(*old, user); err != nil {
+					return err
+				}
+			}
+
+			learner.ID = old.ID
+
+			err = a.db.Update(ctx, old,
+				rel.Set("status", LearnerActive),
+				rel.Set("enrolled_at", now),
+				rel.Set("dropped_at", nil),
+				rel.Set("dropped_by", nil),
+				rel.Set("waiting_since", learner.WaitingSince))
+			if err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+
+			return nil
+		} else if !errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("db (read): %w", err)
+		}
+
+		if err := a.db.Insert(ctx, learner); err != nil {
+			if errors.Is(err, rel.ConstraintError{Type: rel.UniqueConstraint}) {
+				return fmt.Errorf("%w: jam lernanto", lib.ErrHTTPConflict)
+			}
+
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Learner{}, err
+	}
+
+	return *learner, nil
+}
+
+// lockCourseForLearners locks a course until the end of the transaction, and counts the
+// learners who are taking up places, i.e. not waiting, finished or gone.
+func (a *back) lockCourseForLearners(ctx context.Context, id DBID) (Course, int, error) {
+	course := &Course{}
+
+	if err := a.db.Find(ctx, course, where.Eq("id", id), rel.ForUpdate()); err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Course{}, 0, err
+		}
+
+		return Course{}, 0, fmt.Errorf("db (read): %w", err)
+	}
+
+	enrolled, err := a.db.Count(ctx, "learners", where.Eq("course", id), where.Nil("waiting_since"),
+		where.In("status", LearnerActive, LearnerPaused))
+	if err != nil {
+		return Course{}, 0, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *course, enrolled, nil
+}
+
+// promoteFromWaitlist moves learners off the waitlist, oldest first, while there is room.
+func (a *back) promoteFromWaitlist(ctx context.Context, id DBID) ([]Learner, error) {
+	var promoted []Learner
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		course, enrolled, err := a.lockCourseForLearners(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		queriers := []rel.Querier{
+			where.Eq("course", id),
+			where.NotNil("waiting_since"),
+			where.InString("status", waitingStatuses),
+			rel.SortAsc("waiting_since"),
+		}
+
+		if n := placesLeft(course, enrolled); n == 0 {
+			return nil
+		} else if n > 0 {
+			queriers = append(queriers, rel.Limit(n))
+		}
+
+		if err := a.db.FindAll(ctx, &promoted, queriers...); err != nil {
+			return fmt.Errorf("db (read): %w", err)
+		}
+
+		if len(promoted) == 0 {
+			return nil
+		}
+
+		ids := make([]DBID, 0, len(promoted))
+		for i := range promoted {
+			ids = append(ids, promoted[i].ID)
+			promoted[i].WaitingSince = nil
+		}
+
+		_, err = a.db.UpdateAny(ctx, rel.From("learners").Where(where.InString("id", dbidsToStrings(ids))),
+			rel.Set("waiting_since", nil))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range promoted {
+		a.log(ctx).Info("promoted from waitlist", "course", id, "learner", l.ID)
+	}
+
+	return promoted, nil
+}
+
+// removeLearner really deletes a learner, and all their homework, letting in the next one
+// from the waitlist.
+func (a *back) removeLearner(ctx context.Context, learner Learner) error {
+	return a.db.Transaction(ctx, func(ctx context.Context) error {
+		if err := a.db.Delete(ctx, &learner); err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		_, err := a.promoteFromWaitlist(ctx, learner.CourseID)
+
+		return err
+	})
+}
+
+// setLearnerStatus moves a learner to a new status, recording when, and who dropped them. A
+// learner who comes back to a full course goes on the waitlist, and one who leaves makes room
+// for others.
+func (a *back) setLearnerStatus(ctx context.Context, learner Learner, to LearnerStatus, by DBID) (Learner, error) {
+	now := time.Now()
+
+	mutates := []rel.Mutate{
+		rel.Set("status", to),
+	}
+
+	if column := learnerStatusTime(learner.Status, to); column != "" {
+		mutates = append(mutates, rel.Set(column, now))
+	}
+
+	// leaving gives up any place on the waitlist
+	if to == LearnerCompleted || to == LearnerDropped {
+		mutates = append(mutates, rel.Set("waiting_since", nil))
 	}
 
-	if err := a.db.Insert(ctx, learner); err != nil {
+	if to == LearnerDropped {
+		mutates = append(mutates, rel.Set("dropped_by", by))
+	}
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		course, enrolled, err := a.lockCourseForLearners(ctx, learner.CourseID)
+		if err != nil {
+			return err
+		}
+
+		returning := learner.Status == LearnerCompleted || learner.Status == LearnerDropped
+		if returning && to == LearnerActive {
+			mutates = append(mutates, rel.Set("dropped_at", nil), rel.Set("dropped_by", nil), rel.Set("completed_at", nil))
+
+			if placesLeft(course, enrolled) == 0 {
+				mutates = append(mutates, rel.Set("waiting_since", now))
+			}
+		}
+
+		n, err := a.db.UpdateAny(ctx, rel.From("learners").Where(where.Eq("id", learner.ID), where.Eq("status", learner.Status)), mutates...)
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		if n == 0 {
+			return fmt.Errorf("%w: stato jam ŝanĝiĝis", lib.ErrHTTPConflict)
+		}
+
+		if to == LearnerCompleted || to == LearnerDropped {
+			if _, err := a.promoteFromWaitlist(ctx, learner.CourseID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
 		return Learner{}, err
 	}
 
+	return a.getLearner(ctx, learner.ID)
+}
+
+// waitlistPosition is the place of a waiting learner in the queue, counting from 1.
+func (a *back) waitlistPosition(ctx context.Context, learner Learner) (int, error) {
+	if learner.WaitingSince == nil {
+		return 0, nil
+	}
+
+	n, err := a.db.Count(ctx, "learners", where.Eq("course", learner.CourseID), where.Lte("waiting_since", *learner.WaitingSince),
+		where.InString("status", waitingStatuses))
+	if err != nil {
+		return 0, fmt.Errorf("db (read): %w", err)
+	}
+
+	return n, nil
+}
+
+func (a *back) getLearner(ctx context.Context, id DBID) (Learner, error) {
+	learner := &Learner{}
+
+	err := a.db.Find(ctx, learner, rel.Select("*", "user_x.*").JoinAssoc("user_x"), where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Learner{}, err
+		}
+
+		return Learner{}, fmt.Errorf("db (read): %w", err)
+	}
+
 	return *learner, nil
 }
 
 func (a *back) getLearnersByCourse(ctx context.Context, course DBID) ([]Learner, error) {
 	var out []Learner
 
-	err := a.db.FindAll(ctx, &out, where.Eq("course", course))
+	err := a.db.FindAll(ctx, &out, rel.Select("*", "user_x.*").JoinAssoc("user_x"), where.Eq("course", course))
 	if err != nil {
 		return nil, fmt.Errorf("db (read): %w", err)
 	}
@@ -206,6 +659,16 @@ func (a *back) putLesson(ctx context.Context, lesson Lesson) (Lesson, error) {
 		lesson.ID = makeRandomID("ke", 5)
 	}
 
+	if lesson.Position == 0 {
+		// new lessons go on the end
+		n, err := a.db.Count(ctx, "lessons", where.Eq("course", lesson.Course))
+		if err != nil {
+			return Lesson{}, fmt.Errorf("db (read): %w", err)
+		}
+
+		lesson.Position = n + 1
+	}
+
 	if err := a.db.Insert(ctx, &lesson); err != nil {
 		return Lesson{}, err
 	}
@@ -231,7 +694,7 @@ func (a *back) getLesson(ctx context.Context, id DBID) (Lesson, error) {
 func (a *back) getLessonsForCourse(ctx context.Context, course DBID) ([]Lesson, error) {
 	var out []Lesson
 
-	err := a.db.FindAll(ctx, &out, where.Eq("course", course), rel.SortDesc("time"))
+	err := a.db.FindAll(ctx, &out, where.Eq("course", course), rel.SortAsc("position"), rel.SortDesc("time"))
 	if err != nil {
 		return nil, fmt.Errorf("db (read): %w", err)
 	}
@@ -239,42 +702,249 @@ func (a *back) getLessonsForCourse(ctx context.Context, course DBID) ([]Lesson,
 	return out, nil
 }
 
-func (a *back) putHomework(ctx context.Context, lernanto DBID, teksto string) (Homework, error) {
+func (a *back) updateLesson(ctx context.Context, lesson Lesson, mutates ...rel.Mutate) (Lesson, error) {
+	if err := a.db.Update(ctx, &lesson, asMutators(mutates)...); err != nil {
+		return Lesson{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return lesson, nil
+}
+
+// deleteLesson really deletes a lesson, and by cascade all homework for it.
+func (a *back) deleteLesson(ctx context.Context, lesson Lesson) error {
+	if err := a.db.Delete(ctx, &lesson); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// setLessonOrder numbers the lessons of a course in the given order, which must include
+// every lesson of the course exactly once.
+func (a *back) setLessonOrder(ctx context.Context, course DBID, order []DBID) ([]Lesson, error) {
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		lessons, err := a.getLessonsForCourse(ctx, course)
+		if err != nil {
+			return err
+		}
+
+		known := map[DBID]bool{}
+		for _, l := range lessons {
+			known[l.ID] = true
+		}
+
+		if len(order) != len(known) {
+			return fmt.Errorf("%w: ordo devas enhavi ĉiujn erojn", lib.ErrHTTPBadRequest)
+		}
+
+		for i, id := range order {
+			if !known[id] {
+				return fmt.Errorf("%w: ordo devas enhavi ĉiujn erojn", lib.ErrHTTPBadRequest)
+			}
+
+			delete(known, id)
+
+			_, err := a.db.UpdateAny(ctx, rel.From("lessons").Where(where.Eq("id", id)), rel.Set("position", i+1))
+			if err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.getLessonsForCourse(ctx, course)
+}
+
+// getLearnerForCourse finds the learner that a user is in a course, if active and not on
+// the waitlist.
+func (a *back) getLearnerForCourse(ctx context.Context, user, course DBID) (Learner, error) {
+	learner := &Learner{}
+
+	err := a.db.Find(ctx, learner, where.Eq("user", user), where.Eq("course", course), where.Nil("waiting_since"),
+		where.Eq("status", LearnerActive))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Learner{}, err
+		}
+
+		return Learner{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *learner, nil
+}
+
+// putHomework stores new homework, which must have its learner and lesson filled in.
+func (a *back) putHomework(ctx context.Context, homework0 Homework) (Homework, error) {
+	learner, lesson, text, status := homework0.LearnerX, homework0.LessonX, homework0.Text, homework0.Status
+
 	homework1 := &Homework{
-		ID:        makeRandomID("ht", 5),
-		LearnerID: lernanto,
-		Text:      teksto,
+		ID:           makeRandomID("ht", 5),
+		LearnerID:    learner.ID,
+		LessonID:     lesson.ID,
+		AssignmentID: homework0.AssignmentID,
+		Late:         homework0.Late,
+		Text:         text,
+		Status:       status,
+	}
+
+	if status == HomeworkSubmitted {
+		now := time.Now()
+		homework1.SubmittedAt = &now
 	}
 
-	if err := a.db.Insert(ctx, homework1); err != nil {
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		if err := a.db.Insert(ctx, homework1); err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		if status == HomeworkSubmitted {
+			if _, err := a.putRevision(ctx, homework1.ID, text); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
 		return Homework{}, err
 	}
 
+	homework1.LearnerX = learner
+	homework1.LessonX = lesson
+
 	return *homework1, nil
 }
 
-func (a *back) getHomeworksForUser(ctx context.Context, userID DBID) ([]Homework, error) {
+// preloadHomeworks fills in the lesson, learner and user of some homework, for display.
+func (a *back) preloadHomeworks(ctx context.Context, homeworks any) error {
+	for _, field := range []string{"lesson_x", "learner_x", "learner_x.user_x"} {
+		if err := a.db.Preload(ctx, homeworks, field); err != nil {
+			return fmt.Errorf("db (read): %w", err)
+		}
+	}
+
+	return nil
+}
+
+// whereHomeworkStatus filters by status, if any are given.
+func whereHomeworkStatus(statuses []HomeworkStatus) []rel.Querier {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	values := make([]any, 0, len(statuses))
+	for _, s := range statuses {
+		values = append(values, s)
+	}
+
+	return []rel.Querier{where.In("homeworks.status", values...)}
+}
+
+func (a *back) getHomeworksForUser(ctx context.Context, userID DBID, statuses []HomeworkStatus) ([]Homework, error) {
 	var out []Homework
 
-	err := a.db.FindAll(ctx, &out, where.Eq("user", userID))
+	queriers := []rel.Querier{
+		rel.Select("homeworks.*").JoinAssoc("learner_x"),
+		where.Eq("learner_x.user", userID),
+		rel.SortDesc("homeworks.created_at"),
+	}
+
+	err := a.db.FindAll(ctx, &out, append(queriers, whereHomeworkStatus(statuses)...)...)
 	if err != nil {
 		return nil, fmt.Errorf("db (read): %w", err)
 	}
 
+	if err := a.preloadHomeworks(ctx, &out); err != nil {
+		return nil, err
+	}
+
 	return out, nil
 }
 
-func (a *back) getHomeworksForLesson(ctx context.Context, course, lessonID DBID) ([]Homework, error) {
+// getHomeworksForLesson finds homework for teachers to see, so never drafts.
+func (a *back) getHomeworksForLesson(ctx context.Context, course, lessonID DBID, statuses []HomeworkStatus) ([]Homework, error) {
 	var out []Homework
 
-	err := a.db.FindAll(ctx, &out, where.Eq("lesson", lessonID))
+	queriers := []rel.Querier{
+		where.Eq("lesson", lessonID),
+		where.Ne("status", HomeworkDraft),
+		rel.SortAsc("submitted_at"),
+	}
+
+	err := a.db.FindAll(ctx, &out, append(queriers, whereHomeworkStatus(statuses)...)...)
 	if err != nil {
 		return nil, fmt.Errorf("db (read): %w", err)
 	}
 
+	if err := a.preloadHomeworks(ctx, &out); err != nil {
+		return nil, err
+	}
+
 	return out, nil
 }
 
+// updateHomeworkText changes the text of a draft.
+func (a *back) updateHomeworkText(ctx context.Context, homework Homework, text string) (Homework, error) {
+	n, err := a.db.UpdateAny(ctx, rel.From("homeworks").Where(where.Eq("id", homework.ID), where.Eq("status", HomeworkDraft)),
+		rel.Set("teksto", text), rel.Set("updated_at", time.Now()))
+	if err != nil {
+		return Homework{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	if n == 0 {
+		return Homework{}, fmt.Errorf("%w: ne plu malneto", lib.ErrHTTPConflict)
+	}
+
+	homework.Text = text
+
+	return homework, nil
+}
+
+// setHomeworkStatus moves homework along, recording when, and who is reviewing it. The update
+// only happens if the status has not changed since the homework was read, so that two teachers
+// cannot both claim the same homework. Claimed homework can only be moved on by whoever claimed
+// it, unless override is set.
+func (a *back) setHomeworkStatus(ctx context.Context, homework Homework, to HomeworkStatus, by *User, override bool) (Homework, error) {
+	if err := checkReviewer(homework, by.ID, override); err != nil {
+		return Homework{}, err
+	}
+
+	homework1, mutates := homeworkStatusChange(homework, to, by.ID, time.Now())
+
+	filters := []rel.FilterQuery{where.Eq("id", homework.ID), where.Eq("status", homework.Status)}
+	if slices.Contains(claimedStatuses, homework.Status) && !override {
+		filters = append(filters, where.Eq("reviewer", by.ID))
+	}
+
+	n, err := a.db.UpdateAny(ctx, rel.From("homeworks").Where(filters...), mutates...)
+	if err != nil {
+		return Homework{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	if n == 0 {
+		return Homework{}, fmt.Errorf("%w: stato jam ŝanĝiĝis", lib.ErrHTTPConflict)
+	}
+
+	return homework1, nil
+}
+
+func (a *back) countHomeworksForLessons(ctx context.Context, lessons ...DBID) (int, error) {
+	if len(lessons) == 0 {
+		return 0, nil
+	}
+
+	n, err := a.db.Count(ctx, "homeworks", where.InString("lesson", dbidsToStrings(lessons)))
+	if err != nil {
+		return 0, fmt.Errorf("db (read): %w", err)
+	}
+
+	return n, nil
+}
+
 func (a *back) getHomework(ctx context.Context, id DBID) (Homework, error) {
 	homework := &Homework{}
 
@@ -287,5 +957,9 @@ func (a *back) getHomework(ctx context.Context, id DBID) (Homework, error) {
 		return Homework{}, fmt.Errorf("db (read): %w", err)
 	}
 
+	if err := a.preloadHomeworks(ctx, homework); err != nil {
+		return Homework{}, err
+	}
+
 	return *homework, nil
 }
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// apiTokenTouchInterval is how out of date the last use of a token is allowed to be, to save
+// writing on every request.
+const apiTokenTouchInterval = time.Minute
+
+// putAPIToken makes a new API token for a user, returning the token itself. Only a hash of it
+// is stored.
+func (a *back) putAPIToken(ctx context.Context, user User, name string, scopes []string, expires *time.Time) (APIToken, string, error) {
+	token, err := lib.MakeSecretToken("api", 32)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+
+	at := APIToken{
+		ID:        makeRandomID("je", 5),
+		UserID:    user.ID,
+		Name:      name,
+		Hash:      lib.HashToken(token),
+		Scopes:    strings.Join(scopes, ","),
+		CreatedAt: time.Now(),
+		ExpiresAt: expires,
+	}
+
+	if err := a.db.Insert(ctx, &at); err != nil {
+		return APIToken{}, "", fmt.Errorf("db (write): %w", err)
+	}
+
+	return at, token, nil
+}
+
+func (a *back) getAPITokensForUser(ctx context.Context, user DBID) ([]APIToken, error) {
+	var out []APIToken
+
+	err := a.db.FindAll(ctx, &out, where.Eq("user", user), rel.SortAsc("created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) getAPIToken(ctx context.Context, id DBID) (APIToken, error) {
+	at := &APIToken{}
+
+	err := a.db.Find(ctx, at, where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return APIToken{}, err
+		}
+
+		return APIToken{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *at, nil
+}
+
+func (a *back) deleteAPIToken(ctx context.Context, at APIToken) error {
+	if err := a.db.Delete(ctx, &at); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// deleteUserAPITokens revokes all API tokens of a user.
+func (a *back) deleteUserAPITokens(ctx context.Context, user DBID) error {
+	_, err := a.db.DeleteAny(ctx, rel.From("api_tokens").Where(where.Eq("user", user)))
+	if err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// useAPIToken finds a live API token, with its user, and notes that it was used. Unknown and
+// expired tokens, and those of deleted users, are all [rel.ErrNotFound].
+func (a *back) useAPIToken(ctx context.Context, token string) (APIToken, error) {
+	at := &APIToken{}
+
+	err := a.db.Find(ctx, at, rel.Select("*", "user_x.*").JoinAssoc("user_x"), where.Eq("hash", lib.HashToken(token)))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return APIToken{}, err
+		}
+
+		return APIToken{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	now := time.Now()
+
+	if (at.ExpiresAt != nil && !now.Before(*at.ExpiresAt)) || at.UserX.DeletedAt != nil {
+		return APIToken{}, rel.ErrNotFound
+	}
+
+	if at.LastUsedAt == nil || now.Sub(*at.LastUsedAt) > apiTokenTouchInterval {
+		_, err := a.db.UpdateAny(ctx, rel.From("api_tokens").Where(where.Eq("id", at.ID)), rel.Set("last_used_at", now))
+		if err != nil {
+			return APIToken{}, fmt.Errorf("db (write): %w", err)
+		}
+
+		at.LastUsedAt = &now
+	}
+
+	return *at, nil
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+)
+
+func (a *back) putAssignment(ctx context.Context, assignment Assignment) (Assignment, error) {
+	if assignment.ID == "" {
+		assignment.ID = makeRandomID("t", 5)
+	}
+
+	if err := a.db.Insert(ctx, &assignment); err != nil {
+		return Assignment{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return assignment, nil
+}
+
+func (a *back) getAssignment(ctx context.Context, id DBID) (Assignment, error) {
+	assignment := &Assignment{}
+
+	err := a.db.Find(ctx, assignment, rel.Select("*", "lesson_x.*").JoinAssoc("lesson_x"), where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Assignment{}, err
+		}
+
+		return Assignment{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *assignment, nil
+}
+
+func (a *back) getAssignmentsForLesson(ctx context.Context, lesson DBID) ([]Assignment, error) {
+	var out []Assignment
+
+	err := a.db.FindAll(ctx, &out, where.Eq("lesson", lesson), rel.SortAsc("due_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+// getAssignmentsForCourses finds all assignments of unarchived lessons in some courses.
+func (a *back) getAssignmentsForCourses(ctx context.Context, courses []DBID) ([]Assignment, error) {
+	if len(courses) == 0 {
+		return nil, nil
+	}
+
+	var out []Assignment
+
+	err := a.db.FindAll(ctx, &out,
+		rel.Select("assignments.*", "lesson_x.*").JoinAssoc("lesson_x"),
+		where.InString("lesson_x.course", dbidsToStrings(courses)),
+		where.Nil("lesson_x.archived_at"),
+		rel.SortAsc("assignments.due_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) updateAssignment(ctx context.Context, assignment Assignment, mutates ...rel.Mutate) (Assignment, error) {
+	if err := a.db.Update(ctx, &assignment, asMutators(mutates)...); err != nil {
+		return Assignment{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return assignment, nil
+}
+
+func (a *back) deleteAssignment(ctx context.Context, assignment Assignment) error {
+	if err := a.db.Delete(ctx, &assignment); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// getSubmittedAssignments finds which of some assignments a user has ever submitted homework for.
+func (a *back) getSubmittedAssignments(ctx context.Context, user DBID, assignments []DBID) (map[DBID]bool, error) {
+	out := map[DBID]bool{}
+
+	if len(assignments) == 0 {
+		return out, nil
+	}
+
+	var homeworks []Homework
+
+	err := a.db.FindAll(ctx, &homeworks,
+		rel.Select("homeworks.*").JoinAssoc("learner_x"),
+		where.Eq("learner_x.user", user),
+		where.InString("homeworks.assignment", dbidsToStrings(assignments)),
+		where.NotNil("homeworks.submitted_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	for _, h := range homeworks {
+		if h.AssignmentID != nil {
+			out[*h.AssignmentID] = true
+		}
+	}
+
+	return out, nil
+}
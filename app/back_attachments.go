@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+)
+
+func (a *back) putAttachment(ctx context.Context, attachment Attachment) (Attachment, error) {
+	if err := a.db.Insert(ctx, &attachment); err != nil {
+		return Attachment{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return attachment, nil
+}
+
+func (a *back) getAttachment(ctx context.Context, id DBID) (Attachment, error) {
+	attachment := &Attachment{}
+
+	err := a.db.Find(ctx, attachment, rel.Select("*", "uploader_x.*").JoinAssoc("uploader_x"), where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Attachment{}, err
+		}
+
+		return Attachment{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *attachment, nil
+}
+
+func (a *back) getAttachmentsForLesson(ctx context.Context, lesson DBID) ([]Attachment, error) {
+	return a.findAttachments(ctx, where.Eq("attachments.lesson", lesson))
+}
+
+func (a *back) getAttachmentsForHomework(ctx context.Context, homework DBID) ([]Attachment, error) {
+	return a.findAttachments(ctx, where.Eq("attachments.homework", homework))
+}
+
+func (a *back) findAttachments(ctx context.Context, filter rel.Querier) ([]Attachment, error) {
+	var out []Attachment
+
+	err := a.db.FindAll(ctx, &out,
+		rel.Select("*", "uploader_x.*").JoinAssoc("uploader_x"),
+		filter,
+		rel.SortAsc("attachments.created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) deleteAttachment(ctx context.Context, attachment Attachment) error {
+	if err := a.db.Delete(ctx, &attachment); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+)
+
+func (a *back) putComment(ctx context.Context, comment Comment) (Comment, error) {
+	if comment.ID == "" {
+		comment.ID = makeRandomID("kom", 5)
+	}
+
+	if err := a.db.Insert(ctx, &comment); err != nil {
+		return Comment{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return comment, nil
+}
+
+func (a *back) getCommentsForHomework(ctx context.Context, homework DBID) ([]Comment, error) {
+	var out []Comment
+
+	err := a.db.FindAll(ctx, &out,
+		rel.Select("*", "author_x.*").JoinAssoc("author_x"),
+		where.Eq("homework", homework),
+		rel.SortAsc("created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) getComment(ctx context.Context, id DBID) (Comment, error) {
+	comment := &Comment{}
+
+	err := a.db.Find(ctx, comment, rel.Select("*", "author_x.*").JoinAssoc("author_x"), where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Comment{}, err
+		}
+
+		return Comment{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *comment, nil
+}
+
+func (a *back) updateCommentText(ctx context.Context, comment Comment, text string) (Comment, error) {
+	err := a.db.Update(ctx, &comment, rel.Set("text", text), rel.Set("updated_at", time.Now()))
+	if err != nil {
+		return Comment{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return comment, nil
+}
+
+// removeComment takes a comment back. If nobody has replied yet it is really deleted,
+// otherwise it is emptied and marked, so that the replies still have something to hang from.
+func (a *back) removeComment(ctx context.Context, comment Comment) error {
+	return a.db.Transaction(ctx, func(ctx context.Context) error {
+		replies, err := a.db.Count(ctx, "comments", where.Eq("parent", comment.ID))
+		if err != nil {
+			return fmt.Errorf("db (read): %w", err)
+		}
+
+		if replies == 0 {
+			if err := a.db.Delete(ctx, &comment); err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+
+			return nil
+		}
+
+		err = a.db.Update(ctx, &comment, rel.Set("text", ""), rel.Set("start", nil), rel.Set("length", nil),
+			rel.Set("removed_at", time.Now()))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		return nil
+	})
+}
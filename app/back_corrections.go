@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+)
+
+func (a *back) putCorrection(ctx context.Context, correction Correction) (Correction, error) {
+	if correction.ID == "" {
+		correction.ID = makeRandomID("ko", 5)
+	}
+
+	annotations := correction.Annotations
+	correction.Annotations = nil
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		if err := a.db.Insert(ctx, &correction); err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		for i := range annotations {
+			annotations[i].ID = makeRandomID("ri", 6)
+			annotations[i].CorrectionID = correction.ID
+		}
+
+		if len(annotations) > 0 {
+			if err := a.db.InsertAll(ctx, &annotations); err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Correction{}, err
+	}
+
+	correction.Annotations = annotations
+
+	return correction, nil
+}
+
+func (a *back) getCorrectionsForHomework(ctx context.Context, homework DBID) ([]Correction, error) {
+	var out []Correction
+
+	err := a.db.FindAll(ctx, &out, where.Eq("homework", homework), rel.SortAsc("created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	if err := a.db.Preload(ctx, &out, "teacher_x"); err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	if err := a.db.Preload(ctx, &out, "annotations", rel.SortAsc("start")); err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) getCorrection(ctx context.Context, id DBID) (Correction, error) {
+	correction := &Correction{}
+
+	err := a.db.Find(ctx, correction, where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Correction{}, err
+		}
+
+		return Correction{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *correction, nil
+}
+
+func (a *back) deleteCorrection(ctx context.Context, correction Correction) error {
+	if err := a.db.Delete(ctx, &correction); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+)
+
+func (a *back) putRubric(ctx context.Context, rubric Rubric) (Rubric, error) {
+	if rubric.ID == "" {
+		rubric.ID = makeRandomID("ru", 5)
+	}
+
+	criteria := rubric.Criteria
+	rubric.Criteria = nil
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		if err := a.db.Insert(ctx, &rubric); err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		for i := range criteria {
+			criteria[i].ID = makeRandomID("kr", 5)
+			criteria[i].RubricID = rubric.ID
+			criteria[i].Position = i + 1
+		}
+
+		if len(criteria) > 0 {
+			if err := a.db.InsertAll(ctx, &criteria); err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Rubric{}, err
+	}
+
+	rubric.Criteria = criteria
+
+	return rubric, nil
+}
+
+func (a *back) getRubric(ctx context.Context, id DBID) (Rubric, error) {
+	rubric := &Rubric{}
+
+	err := a.db.Find(ctx, rubric, where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Rubric{}, err
+		}
+
+		return Rubric{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	if err := a.db.Preload(ctx, rubric, "criteria", rel.SortAsc("position")); err != nil {
+		return Rubric{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *rubric, nil
+}
+
+func (a *back) getRubricsForCourse(ctx context.Context, course DBID) ([]Rubric, error) {
+	var out []Rubric
+
+	err := a.db.FindAll(ctx, &out, where.Eq("course", course), rel.SortAsc("created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	if err := a.db.Preload(ctx, &out, "criteria", rel.SortAsc("position")); err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+// deleteRubric deletes a rubric, and with it all grades given using it.
+func (a *back) deleteRubric(ctx context.Context, rubric Rubric) error {
+	if err := a.db.Delete(ctx, &rubric); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// putGrades replaces all the grades of some homework.
+func (a *back) putGrades(ctx context.Context, homework DBID, teacher DBID, grades []Grade) ([]Grade, error) {
+	now := time.Now()
+
+	for i := range grades {
+		grades[i].ID = makeRandomID("no", 5)
+		grades[i].HomeworkID = homework
+		grades[i].TeacherID = teacher
+		grades[i].CreatedAt = now
+		grades[i].UpdatedAt = now
+	}
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := a.db.DeleteAny(ctx, rel.From("grades").Where(where.Eq("homework", homework))); err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		if len(grades) > 0 {
+			if err := a.db.InsertAll(ctx, &grades); err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grades, nil
+}
+
+func (a *back) getGradesForHomework(ctx context.Context, homework DBID) ([]Grade, error) {
+	var out []Grade
+
+	err := a.db.FindAll(ctx, &out,
+		rel.Select("grades.*", "criterion_x.*").JoinAssoc("criterion_x"),
+		where.Eq("grades.homework", homework),
+		rel.SortAsc("criterion_x.position"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+// getGradedHomeworksForCourse finds all the homework done for assignments of a course, and
+// all the grades given to it.
+func (a *back) getGradedHomeworksForCourse(ctx context.Context, course DBID) ([]Homework, []Grade, error) {
+	var homeworks []Homework
+
+	err := a.db.FindAll(ctx, &homeworks,
+		rel.Select("homeworks.*").JoinAssoc("lesson_x"),
+		where.Eq("lesson_x.course", course),
+		where.NotNil("homeworks.assignment"),
+		where.Ne("homeworks.status", HomeworkDraft))
+	if err != nil {
+		return nil, nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	if len(homeworks) == 0 {
+		return nil, nil, nil
+	}
+
+	ids := make([]DBID, 0, len(homeworks))
+	for _, h := range homeworks {
+		ids = append(ids, h.ID)
+	}
+
+	var grades []Grade
+
+	err = a.db.FindAll(ctx, &grades,
+		rel.Select("grades.*", "criterion_x.*").JoinAssoc("criterion_x"),
+		where.InString("grades.homework", dbidsToStrings(ids)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return homeworks, grades, nil
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// joinCourse makes a user a learner of a course, using up one use of the join code. The code
+// is checked again in the update, so that two users can't both take the last use.
+func (a *back) joinCourse(ctx context.Context, user DBID, course Course, code string) (Learner, error) {
+	var learner Learner
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		n, err := a.db.UpdateAny(ctx, rel.From("courses").Where(
+			where.Eq("id", course.ID),
+			where.Ne("join_code", ""),
+			where.Eq("join_code", code),
+			where.Or(where.Nil("join_expires_at"), where.Gt("join_expires_at", time.Now())),
+			where.Or(where.Eq("join_max_uses", 0), where.Fragment("join_uses < join_max_uses")),
+		), rel.Inc("join_uses"))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		if n == 0 {
+			return fmt.Errorf("%w: kodo ne plu validas", lib.ErrHTTPForbidden)
+		}
+
+		learner, err = a.addUserToCourse(ctx, user, course.ID, true)
+
+		return err
+	})
+	if err != nil {
+		return Learner{}, err
+	}
+
+	return learner, nil
+}
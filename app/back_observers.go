@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func (a *back) addObserverToCourse(ctx context.Context, user, course DBID) (Observer, error) {
+	observer := &Observer{
+		ID:       makeRandomID("ob", 5),
+		UserID:   user,
+		CourseID: course,
+	}
+
+	if err := a.db.Insert(ctx, observer); err != nil {
+		if errors.Is(err, rel.ConstraintError{Type: rel.UniqueConstraint}) {
+			return Observer{}, fmt.Errorf("%w: jam observanto", lib.ErrHTTPConflict)
+		}
+
+		return Observer{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return *observer, nil
+}
+
+func (a *back) getObserversByCourse(ctx context.Context, course DBID) ([]Observer, error) {
+	var out []Observer
+
+	err := a.db.FindAll(ctx, &out, rel.Select("*", "user_x.*").JoinAssoc("user_x"), where.Eq("course", course))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) getObserver(ctx context.Context, id DBID) (Observer, error) {
+	observer := &Observer{}
+
+	err := a.db.Find(ctx, observer, where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Observer{}, err
+		}
+
+		return Observer{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *observer, nil
+}
+
+func (a *back) removeObserver(ctx context.Context, observer Observer) error {
+	if err := a.db.Delete(ctx, &observer); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// putOIDCLogin remembers a login that is going to the provider, under a hash of its state.
+func (a *back) putOIDCLogin(ctx context.Context, state, nonce, verifier string, ttl time.Duration) error {
+	now := time.Now()
+
+	login := OIDCLogin{
+		ID:        DBID(lib.HashToken(state)),
+		Nonce:     nonce,
+		Verifier:  verifier,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err := a.db.Insert(ctx, &login); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// useOIDCLogin finds a login that has come back from the provider, and deletes it, so that it
+// cannot come back twice. Unknown, expired and used logins are all [rel.ErrNotFound].
+func (a *back) useOIDCLogin(ctx context.Context, state string) (OIDCLogin, error) {
+	id := DBID(lib.HashToken(state))
+
+	login := OIDCLogin{}
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		err := a.db.Find(ctx, &login, where.Eq("id", id))
+		if err != nil {
+			if errors.Is(err, rel.ErrNotFound) {
+				return err
+			}
+			return fmt.Errorf("db (read): %w", err)
+		}
+
+		n, err := a.db.DeleteAny(ctx, rel.From("oidc_logins").Where(where.Eq("id", id)))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		if n == 0 || !time.Now().Before(login.ExpiresAt) {
+			return rel.ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return OIDCLogin{}, err
+	}
+
+	return login, nil
+}
+
+// deleteExpiredOIDCLogins removes logins that never came back from the provider in time.
+func (a *back) deleteExpiredOIDCLogins(ctx context.Context, before time.Time) (int, error) {
+	n, err := a.db.DeleteAny(ctx, rel.From("oidc_logins").Where(where.Lt("expires_at", before)))
+	if err != nil {
+		return 0, fmt.Errorf("db (write): %w", err)
+	}
+
+	return n, nil
+}
+
+// getOIDCUser finds the user that the provider says someone is, by email, or makes one if
+// provision is set. The provider has checked the email, so the user counts as verified. It
+// also says whether an unverified account was claimed, in which case any sessions of whoever
+// registered it must go too.
+func (a *back) getOIDCUser(ctx context.Context, claims lib.OIDCClaims, provision bool) (User, bool, error) {
+	user0, err := oidcUser(claims)
+	if err != nil {
+		return User{}, false, err
+	}
+
+	user1, err := a.getUserByEmail(ctx, user0.Email)
+	if err == nil {
+		claimed := user1.VerifiedAt == nil
+
+		if claimed {
+			user1, err = a.claimUser(ctx, user1)
+			if err != nil {
+				return User{}, false, err
+			}
+		}
+
+		user2, err := a.verifyUser(ctx, user1)
+		if err != nil {
+			return User{}, false, err
+		}
+
+		return user2, claimed, nil
+	}
+
+	if !errors.Is(err, rel.ErrNotFound) || !provision {
+		return User{}, false, err
+	}
+
+	// nobody knows this, so the password can only be set by a reset
+	user0.Password, err = lib.MakeSecretToken("oidc", 32)
+	if err != nil {
+		return User{}, false, err
+	}
+
+	user1, err = a.putUser(ctx, user0)
+	if err != nil {
+		return User{}, false, err
+	}
+
+	return user1, false, nil
+}
+
+// claimUser takes an unverified account away from whoever registered it, since they may not
+// own the email. Their password stops working, and so does anything else they were given.
+func (a *back) claimUser(ctx context.Context, user0 User) (User, error) {
+	password, err := lib.MakeSecretToken("oidc", 32)
+	if err != nil {
+		return User{}, err
+	}
+
+	user1, err := a.setUserPassword(ctx, user0, password)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := a.deleteUserAPITokens(ctx, user1.ID); err != nil {
+		return User{}, err
+	}
+
+	for _, purpose := range []TokenPurpose{TokenVerify, TokenReset} {
+		if err := a.deleteUserTokens(ctx, user1.ID, purpose); err != nil {
+			return User{}, err
+		}
+	}
+
+	return user1, nil
+}
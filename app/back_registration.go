@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// putUserToken makes a single use token for a user, returning the token itself. Only a hash
+// of it is stored.
+func (a *back) putUserToken(ctx context.Context, user User, purpose TokenPurpose, ttl time.Duration) (string, error) {
+	token, err := lib.MakeSecretToken(string(purpose), 32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	ut := UserToken{
+		ID:        DBID(lib.HashToken(token)),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err := a.db.Insert(ctx, &ut); err != nil {
+		return "", fmt.Errorf("db (write): %w", err)
+	}
+
+	return token, nil
+}
+
+// useUserToken finds the user that a token was made for, and deletes the token, so that it
+// cannot be used again. Unknown, expired and already used tokens are all [rel.ErrNotFound].
+func (a *back) useUserToken(ctx context.Context, token string, purpose TokenPurpose) (User, error) {
+	id := DBID(lib.HashToken(token))
+
+	var user User
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		ut := &UserToken{}
+
+		err := a.db.Find(ctx, ut, where.Eq("id", id), where.Eq("purpose", purpose))
+		if err != nil {
+			if errors.Is(err, rel.ErrNotFound) {
+				return err
+			}
+			return fmt.Errorf("db (read): %w", err)
+		}
+
+		// if someone else got here first, then this deletes nothing
+		n, err := a.db.DeleteAny(ctx, rel.From("user_tokens").Where(where.Eq("id", id)))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		if n == 0 || !time.Now().Before(ut.ExpiresAt) {
+			return rel.ErrNotFound
+		}
+
+		user, err = a.getUser(ctx, ut.UserID)
+
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// deleteExpiredUserTokens removes tokens that were never used in time.
+func (a *back) deleteExpiredUserTokens(ctx context.Context, before time.Time) (int, error) {
+	n, err := a.db.DeleteAny(ctx, rel.From("user_tokens").Where(where.Lt("expires_at", before)))
+	if err != nil {
+		return 0, fmt.Errorf("db (write): %w", err)
+	}
+
+	return n, nil
+}
+
+// deleteUserTokens removes all tokens of a user made for some purpose.
+func (a *back) deleteUserTokens(ctx context.Context, user DBID, purpose TokenPurpose) error {
+	_, err := a.db.DeleteAny(ctx, rel.From("user_tokens").Where(where.Eq("user", user), where.Eq("purpose", purpose)))
+	if err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+// reregisterUser gives an unverified user the name and password of a new registration, and
+// forgets tokens sent before. Whoever registered first may not own the email, so nothing of
+// theirs can be left for the owner to verify.
+func (a *back) reregisterUser(ctx context.Context, user0 User, name, password string) (User, error) {
+	hash, err := lib.HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	user1, err := a.updateUser(ctx, user0, rel.Set("name", name), rel.Set("password", hash))
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := a.deleteUserTokens(ctx, user1.ID, TokenVerify); err != nil {
+		return User{}, err
+	}
+
+	return user1, nil
+}
+
+// verifyUser marks that a user has confirmed their email, and forgets any other tokens
+// that were sent to confirm it.
+func (a *back) verifyUser(ctx context.Context, user User) (User, error) {
+	if user.VerifiedAt != nil {
+		return user, nil
+	}
+
+	user1, err := a.updateUser(ctx, user, rel.Set("verified_at", time.Now()))
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := a.deleteUserTokens(ctx, user.ID, TokenVerify); err != nil {
+		return User{}, err
+	}
+
+	return user1, nil
+}
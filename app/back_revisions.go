@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+)
+
+// putRevision records the current text of some homework as its next revision.
+func (a *back) putRevision(ctx context.Context, homework DBID, text string) (HomeworkRevision, error) {
+	n, err := a.db.Count(ctx, "homework_revisions", where.Eq("homework", homework))
+	if err != nil {
+		return HomeworkRevision{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	revision := &HomeworkRevision{
+		ID:         makeRandomID("v", 6),
+		HomeworkID: homework,
+		Number:     n + 1,
+		Text:       text,
+	}
+
+	if err := a.db.Insert(ctx, revision); err != nil {
+		return HomeworkRevision{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return *revision, nil
+}
+
+func (a *back) getRevisions(ctx context.Context, homework DBID) ([]HomeworkRevision, error) {
+	var out []HomeworkRevision
+
+	err := a.db.FindAll(ctx, &out, where.Eq("homework", homework), rel.SortAsc("number"))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) getRevision(ctx context.Context, homework DBID, number int) (HomeworkRevision, error) {
+	revision := &HomeworkRevision{}
+
+	err := a.db.Find(ctx, revision, where.Eq("homework", homework), where.Eq("number", number))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return HomeworkRevision{}, err
+		}
+
+		return HomeworkRevision{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *revision, nil
+}
+
+// submitHomework is when the learner sends homework in, the first time from a draft, or again
+// after it was returned, maybe with new text. Every submission is kept as a revision.
+func (a *back) submitHomework(ctx context.Context, homework Homework, text string, late bool, by *User) (Homework, error) {
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		if text != "" && text != homework.Text {
+			_, err := a.db.UpdateAny(ctx, rel.From("homeworks").Where(where.Eq("id", homework.ID)), rel.Set("teksto", text))
+			if err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+
+			homework.Text = text
+		}
+
+		if late {
+			_, err := a.db.UpdateAny(ctx, rel.From("homeworks").Where(where.Eq("id", homework.ID)), rel.Set("late", true))
+			if err != nil {
+				return fmt.Errorf("db (write): %w", err)
+			}
+
+			homework.Late = true
+		}
+
+		var err error
+
+		homework, err = a.setHomeworkStatus(ctx, homework, HomeworkSubmitted, by, false)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		_, err = a.db.UpdateAny(ctx, rel.From("homeworks").Where(where.Eq("id", homework.ID)), rel.Set("submitted_at", now))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		homework.SubmittedAt = &now
+
+		_, err = a.putRevision(ctx, homework.ID, homework.Text)
+
+		return err
+	})
+	if err != nil {
+		return Homework{}, err
+	}
+
+	return homework, nil
+}
+
+// revisionTexts maps revision numbers to texts, with 0 for the current text, so that
+// corrections can be shown against the text that they were made on.
+func (a *back) revisionTexts(ctx context.Context, homework Homework) (map[int]string, error) {
+	revisions, err := a.getRevisions(ctx, homework.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[int]string{0: homework.Text}
+	for _, r := range revisions {
+		out[r.Number] = r.Text
+	}
+
+	return out, nil
+}
+
+// latestRevision is the number of the last revision of some homework, or 0 if there are none.
+func (a *back) latestRevision(ctx context.Context, homework DBID) (int, error) {
+	n, err := a.db.Count(ctx, "homework_revisions", where.Eq("homework", homework))
+	if err != nil {
+		return 0, fmt.Errorf("db (read): %w", err)
+	}
+
+	return n, nil
+}
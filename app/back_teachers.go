@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func (a *back) addTeacherToCourse(ctx context.Context, user, course DBID) (Teacher, error) {
+	teacher := &Teacher{
+		ID:       makeRandomID("i", 5),
+		UserID:   user,
+		CourseID: course,
+	}
+
+	if err := a.db.Insert(ctx, teacher); err != nil {
+		if errors.Is(err, rel.ConstraintError{Type: rel.UniqueConstraint}) {
+			return Teacher{}, fmt.Errorf("%w: jam instruisto", lib.ErrHTTPConflict)
+		}
+
+		return Teacher{}, fmt.Errorf("db (write): %w", err)
+	}
+
+	return *teacher, nil
+}
+
+func (a *back) getTeachersByCourse(ctx context.Context, course DBID) ([]Teacher, error) {
+	var out []Teacher
+
+	err := a.db.FindAll(ctx, &out, rel.Select("*", "user_x.*").JoinAssoc("user_x"), where.Eq("course", course))
+	if err != nil {
+		return nil, fmt.Errorf("db (read): %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *back) getTeacher(ctx context.Context, id DBID) (Teacher, error) {
+	teacher := &Teacher{}
+
+	err := a.db.Find(ctx, teacher, where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Teacher{}, err
+		}
+
+		return Teacher{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *teacher, nil
+}
+
+func (a *back) removeTeacher(ctx context.Context, teacher Teacher) error {
+	if err := a.db.Delete(ctx, &teacher); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
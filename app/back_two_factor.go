@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// setTOTPSecret keeps a new secret for a user, which doesn't count until it is enabled.
+func (a *back) setTOTPSecret(ctx context.Context, user User, secret string) (User, error) {
+	return a.updateUser(ctx, user, rel.Set("totp_secret", secret))
+}
+
+// enableTOTP starts asking a user for TOTP codes, and gives them new recovery codes.
+func (a *back) enableTOTP(ctx context.Context, user User, step int64, codes []string) (User, error) {
+	var user1 User
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		user1, err = a.updateUser(ctx, user, rel.Set("totp_enabled_at", time.Now()), rel.Set("totp_last_step", step))
+		if err != nil {
+			return err
+		}
+
+		return a.putRecoveryCodes(ctx, user.ID, codes)
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return user1, nil
+}
+
+// disableTOTP stops asking a user for TOTP codes, and forgets their secret and recovery codes.
+func (a *back) disableTOTP(ctx context.Context, user User) (User, error) {
+	var user1 User
+
+	err := a.db.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		user1, err = a.updateUser(ctx, user, rel.Set("totp_secret", ""), rel.Set("totp_enabled_at", nil), rel.Set("totp_last_step", 0))
+		if err != nil {
+			return err
+		}
+
+		_, err = a.db.DeleteAny(ctx, rel.From("recovery_codes").Where(where.Eq("user", user.ID)))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return user1, nil
+}
+
+// useTOTPStep marks a time step as used by a user. If it, or a later one, already was, then
+// this is [rel.ErrNotFound], because the code is being used again.
+func (a *back) useTOTPStep(ctx context.Context, user User, step int64) error {
+	n, err := a.db.UpdateAny(ctx, rel.From("users").Where(where.Eq("id", user.ID), where.Lt("totp_last_step", step)),
+		rel.Set("totp_last_step", step))
+	if err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	if n == 0 {
+		return rel.ErrNotFound
+	}
+
+	return nil
+}
+
+// putRecoveryCodes replaces all recovery codes of a user. Only hashes are stored.
+func (a *back) putRecoveryCodes(ctx context.Context, user DBID, codes []string) error {
+	return a.db.Transaction(ctx, func(ctx context.Context) error {
+		_, err := a.db.DeleteAny(ctx, rel.From("recovery_codes").Where(where.Eq("user", user)))
+		if err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		now := time.Now()
+
+		rcs := make([]RecoveryCode, 0, len(codes))
+		for _, code := range codes {
+			rcs = append(rcs, RecoveryCode{
+				ID:        DBID(lib.HashToken(normalRecoveryCode(code))),
+				UserID:    user,
+				CreatedAt: now,
+			})
+		}
+
+		if err := a.db.InsertAll(ctx, &rcs); err != nil {
+			return fmt.Errorf("db (write): %w", err)
+		}
+
+		return nil
+	})
+}
+
+// useRecoveryCode uses up one of a user's recovery codes, or is [rel.ErrNotFound] if it isn't
+// one.
+func (a *back) useRecoveryCode(ctx context.Context, user User, code string) error {
+	id := DBID(lib.HashToken(normalRecoveryCode(code)))
+
+	n, err := a.db.DeleteAny(ctx, rel.From("recovery_codes").Where(where.Eq("id", id), where.Eq("user", user.ID)))
+	if err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	if n == 0 {
+		return rel.ErrNotFound
+	}
+
+	return nil
+}
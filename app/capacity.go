@@ -0,0 +1,37 @@
+package app
+
+import (
+	"slices"
+)
+
+// placesLeft is how many more learners a course can take before they have to wait, given
+// how many there are now. It is -1 when there is no limit.
+func placesLeft(course Course, enrolled int) int {
+	if course.MaxLearners == 0 {
+		return -1
+	}
+
+	return max(course.MaxLearners-enrolled, 0)
+}
+
+// waitlistPositions gives the place of each waiting learner in the queue, counting from 1.
+func waitlistPositions(learners []Learner) map[DBID]int {
+	var waiting []Learner
+
+	for _, l := range learners {
+		if l.WaitingSince != nil {
+			waiting = append(waiting, l)
+		}
+	}
+
+	slices.SortStableFunc(waiting, func(a, b Learner) int {
+		return a.WaitingSince.Compare(*b.WaitingSince)
+	})
+
+	out := make(map[DBID]int, len(waiting))
+	for i, l := range waiting {
+		out[l.ID] = i + 1
+	}
+
+	return out
+}
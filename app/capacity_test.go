@@ -0,0 +1,27 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlacesLeft(t *testing.T) {
+	assert.Equal(t, -1, placesLeft(Course{}, 100))
+	assert.Equal(t, 2, placesLeft(Course{MaxLearners: 5}, 3))
+	assert.Equal(t, 0, placesLeft(Course{MaxLearners: 5}, 7))
+}
+
+func TestWaitlistPositions(t *testing.T) {
+	t1 := time.Now()
+	t2 := t1.Add(time.Minute)
+
+	positions := waitlistPositions([]Learner{
+		{ID: "l-1"},
+		{ID: "l-2", WaitingSince: &t2},
+		{ID: "l-3", WaitingSince: &t1},
+	})
+
+	assert.Equal(t, map[DBID]int{"l-3": 1, "l-2": 2}, positions)
+}
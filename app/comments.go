@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// checkCommentAnchor makes sure that the part of the text that a comment is about, if any,
+// is really in the text.
+func checkCommentAnchor(text string, start, length *int) error {
+	if start == nil && length == nil {
+		return nil
+	}
+
+	if start == nil || length == nil {
+		return fmt.Errorf("%w: komenco kaj longo iras kune", lib.ErrHTTPBadRequest)
+	}
+
+	if *start < 0 || *length <= 0 || *start+*length > len([]rune(text)) {
+		return fmt.Errorf("%w: komento ekster la teksto", lib.ErrHTTPBadRequest)
+	}
+
+	return nil
+}
+
+// threadComments arranges comments, which must be sorted by time, into threads. Replies
+// to comments that are missing are shown at the top level.
+func threadComments(texts map[int]string, comments []Comment) []CommentJSON {
+	children := map[DBID][]Comment{}
+	known := map[DBID]bool{}
+
+	for _, c := range comments {
+		known[c.ID] = true
+	}
+
+	var roots []Comment
+
+	for _, c := range comments {
+		if c.ParentID != nil && known[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var build func(cs []Comment) []CommentJSON
+
+	build = func(cs []Comment) []CommentJSON {
+		out := make([]CommentJSON, 0, len(cs))
+
+		for _, c := range cs {
+			j := apiFromComment(texts[c.Revision], c)
+			j.Replies = build(children[c.ID])
+			out = append(out, j)
+		}
+
+		return out
+	}
+
+	return build(roots)
+}
+
+func apiFromComment(text string, in Comment) CommentJSON {
+	out := CommentJSON{
+		ID: in.ID,
+		Author: UserJSON{
+			ID:   in.AuthorID,
+			Name: in.AuthorX.Name,
+		},
+		ParentID: in.ParentID,
+		Revision: in.Revision,
+		Created:  in.CreatedAt,
+	}
+
+	if in.RemovedAt != nil {
+		out.Removed = true
+		return out
+	}
+
+	out.Text = in.Text
+
+	if in.UpdatedAt.After(in.CreatedAt) {
+		out.Edited = &in.UpdatedAt
+	}
+
+	if in.Start != nil && in.Length != nil {
+		out.Start, out.Length = in.Start, in.Length
+
+		if runes := []rune(text); *in.Start+*in.Length <= len(runes) {
+			out.Quote = string(runes[*in.Start : *in.Start+*in.Length])
+		}
+	}
+
+	return out
+}
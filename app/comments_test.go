@@ -0,0 +1,43 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCommentAnchor(t *testing.T) {
+	text := "Mi ŝatas manĝi pomoj."
+	start, length, long := 3, 5, 50
+
+	assert.NoError(t, checkCommentAnchor(text, nil, nil))
+	assert.NoError(t, checkCommentAnchor(text, &start, &length))
+
+	assert.Error(t, checkCommentAnchor(text, &start, nil))
+	assert.Error(t, checkCommentAnchor(text, &start, &long))
+}
+
+func TestThreadComments(t *testing.T) {
+	now := time.Now()
+	start, length := 3, 5
+
+	parent := DBID("kom-1")
+	missing := DBID("kom-9")
+
+	comments := []Comment{
+		{ID: "kom-1", Text: "Kial?", Revision: 1, Start: &start, Length: &length, CreatedAt: now, UpdatedAt: now},
+		{ID: "kom-2", Text: "Ĉar...", ParentID: &parent, CreatedAt: now, UpdatedAt: now.Add(time.Minute)},
+		{ID: "kom-3", Text: "sekreto", ParentID: &missing, CreatedAt: now, UpdatedAt: now, RemovedAt: &now},
+	}
+
+	threads := threadComments(map[int]string{1: "Mi ŝatas manĝi pomoj."}, comments)
+
+	assert.Len(t, threads, 2)
+	assert.Equal(t, "ŝatas", threads[0].Quote)
+	assert.Nil(t, threads[0].Edited)
+	assert.Len(t, threads[0].Replies, 1)
+	assert.NotNil(t, threads[0].Replies[0].Edited)
+	assert.True(t, threads[1].Removed)
+	assert.Empty(t, threads[1].Text)
+}
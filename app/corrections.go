@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// annotationCategories are the kinds of mistake that a teacher can mark.
+var annotationCategories = []string{
+	"gramatiko",
+	"ortografio",
+	"vortprovizo",
+	"interpunkcio",
+	"stilo",
+	"alia",
+}
+
+// checkAnnotations makes sure that annotations fit in the text, don't overlap, and have
+// known categories. It sorts them by where they start.
+func checkAnnotations(text string, annotations []Annotation) error {
+	length := len([]rune(text))
+
+	slices.SortFunc(annotations, func(a, b Annotation) int {
+		return a.Start - b.Start
+	})
+
+	end := 0
+
+	for i, an := range annotations {
+		if an.Start < 0 || an.Length < 0 || an.Start+an.Length > length {
+			return fmt.Errorf("%w: rimarko %d ekster la teksto", lib.ErrHTTPBadRequest, i)
+		}
+
+		if an.Start < end {
+			return fmt.Errorf("%w: rimarko %d interkovras alian", lib.ErrHTTPBadRequest, i)
+		}
+
+		if an.Category == "" {
+			annotations[i].Category = "alia"
+		} else if !slices.Contains(annotationCategories, an.Category) {
+			return fmt.Errorf("%w: nekonata kategorio %q", lib.ErrHTTPBadRequest, an.Category)
+		}
+
+		end = an.Start + an.Length
+	}
+
+	return nil
+}
+
+// segmentText cuts text into plain and annotated pieces, in order. The annotations must
+// already have been through [checkAnnotations].
+func segmentText(text string, annotations []AnnotationJSON) []SegmentJSON {
+	runes := []rune(text)
+
+	var out []SegmentJSON
+
+	pos := 0
+
+	for i := range annotations {
+		an := &annotations[i]
+
+		if an.Start > pos {
+			out = append(out, SegmentJSON{Text: string(runes[pos:an.Start])})
+		}
+
+		out = append(out, SegmentJSON{
+			Text:       string(runes[an.Start : an.Start+an.Length]),
+			Annotation: an,
+		})
+
+		pos = an.Start + an.Length
+	}
+
+	if pos < len(runes) {
+		out = append(out, SegmentJSON{Text: string(runes[pos:])})
+	}
+
+	return out
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAnnotations(t *testing.T) {
+	text := "Mi ŝatas manĝi pomoj."
+
+	good := []Annotation{
+		{Start: 15, Length: 5, Replacement: "pomojn", Category: "gramatiko"},
+		{Start: 0, Length: 2},
+	}
+
+	assert.NoError(t, checkAnnotations(text, good))
+	assert.Equal(t, 0, good[0].Start)
+	assert.Equal(t, "alia", good[0].Category)
+
+	assert.Error(t, checkAnnotations(text, []Annotation{{Start: 18, Length: 5}}))
+	assert.Error(t, checkAnnotations(text, []Annotation{{Start: 0, Length: 5}, {Start: 3, Length: 2}}))
+	assert.Error(t, checkAnnotations(text, []Annotation{{Start: 0, Length: 1, Category: "nenio"}}))
+}
+
+func TestSegmentText(t *testing.T) {
+	text := "Mi ŝatas manĝi pomoj."
+
+	segments := segmentText(text, []AnnotationJSON{
+		{Start: 3, Length: 5},
+		{Start: 15, Length: 5, Replacement: "pomojn"},
+	})
+
+	texts := make([]string, 0, len(segments))
+	for _, s := range segments {
+		texts = append(texts, s.Text)
+	}
+
+	assert.Equal(t, []string{"Mi ", "ŝatas", " manĝi ", "pomoj", "."}, texts)
+	assert.Nil(t, segments[0].Annotation)
+	assert.Equal(t, "pomojn", segments[3].Annotation.Replacement)
+}
@@ -3,4 +3,6 @@ package app
 import "errors"
 
 var ErrNoSession = errors.New("neniu seanco")
+var ErrUnverified = errors.New("retpoŝto ne konfirmita")
+var ErrLocked = errors.New("konto provizore ŝlosita")
 var ErrUnimplemented = errors.New("nerealigite")
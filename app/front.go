@@ -6,53 +6,156 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/config"
 	"github.com/undeconstructed/skribserv/lib"
 )
 
 type front struct {
-	back  *back
-	ident *Authenticator
-	log   lib.MakeContextLogger
+	back           *back
+	ident          *Authenticator
+	cookie         http.Cookie
+	store          lib.BlobStore
+	files          config.FilesConfig
+	mail           lib.MailSender
+	publicURL      string
+	registration   config.RegistrationConfig
+	resetTTL       time.Duration
+	twoFactor      config.TwoFactorConfig
+	oidc           *lib.OIDCProvider
+	oidcConfig     config.OIDCConfig
+	limits         *loginLimits
+	resetLimits    *loginLimits
+	registerLimits *loginLimits
+	log            lib.MakeContextLogger
 }
 
 func (a *front) Mount(mux lib.Router) {
 	h := lib.APIHandler
 
 	mux("POST", "/mi/ensaluti", h(a.Login))
+	mux("POST", "/mi/ensaluti/kodo", h(a.PostLoginCode), a.identifyPending)
+	mux("GET", "/mi/ensaluti/oidc", h(a.GetOIDCLogin))
+	mux("GET", "/mi/ensaluti/oidc/reveno", h(a.GetOIDCCallback))
 	mux("POST", "/mi/elsaluti", h(a.Logout))
+	mux("POST", "/mi/registri", h(a.PostRegister))
+	mux("POST", "/mi/konfirmi", h(a.PostVerify))
+	mux("POST", "/mi/pasvorto-forgesita", h(a.PostForgotPassword))
+	mux("POST", "/mi/pasvorto-restarigi", h(a.PostResetPassword))
 	mux("GET", "/mi", h(a.AboutMe), a.identify)
-
-	mux("GET", "/uzantoj", h(a.GetUsers), a.forAdmin, a.identify)
-	mux("POST", "/uzantoj", h(a.PostUsers), a.forAdmin, a.identify)
-	mux("GET", "/uzantoj/{user}", h(a.GetUser), a.forAdminOrSelf, a.identify)
+	mux("GET", "/mi/taskoj", h(a.GetMyAssignments), a.identify)
+	mux("GET", "/mi/ĵetonoj", h(a.GetAPITokens), a.identify)
+	mux("POST", "/mi/ĵetonoj", h(a.PostAPIToken), a.identify)
+	mux("DELETE", "/mi/ĵetonoj/{token}", h(a.DeleteAPIToken), a.identify)
+	mux("POST", "/mi/dufaktora", h(a.PostTwoFactor), a.identify)
+	mux("POST", "/mi/dufaktora/konfirmi", h(a.PostTwoFactorConfirm), a.identify)
+	mux("DELETE", "/mi/dufaktora", h(a.DeleteTwoFactor), a.identify)
+	mux("POST", "/mi/dufaktora/rezervaj-kodoj", h(a.PostRecoveryCodes), a.identify)
+
+	mux("GET", "/uzantoj", h(a.GetUsers), a.need(PermManageUsers), a.identify)
+	mux("POST", "/uzantoj", h(a.PostUsers), a.need(PermManageUsers), a.identify)
+	mux("GET", "/uzantoj/{user}", h(a.GetUser), a.needOrSelf(PermManageUsers), a.identify)
+	mux("PATCH", "/uzantoj/{user}", h(a.PatchUser), a.needOrSelf(PermManageUsers), a.identify)
+	mux("DELETE", "/uzantoj/{user}", h(a.DeleteUser), a.need(PermManageUsers), a.identify)
+	mux("POST", "/uzantoj/{user}/pasvorto", h(a.PostPassword), a.needOrSelf(PermManageUsers), a.identify)
+	mux("DELETE", "/uzantoj/{user}/dufaktora", h(a.DeleteUserTwoFactor), a.need(PermManageUsers), a.identify)
+	mux("POST", "/uzantoj/{user}/malŝlosi", h(a.PostUnlockUser), a.need(PermManageUsers), a.identify)
 
 	mux("GET", "/kursoj", h(a.GetCourses), a.identify)
-	mux("POST", "/kursoj", h(a.PostCourses), a.forAdmin, a.identify)
+	mux("POST", "/kursoj", h(a.PostCourses), a.need(PermCreateCourse), a.identify)
 	mux("GET", "/kursoj/{course}", h(a.GetCourse), a.identify)
+	mux("PATCH", "/kursoj/{course}", h(a.PatchCourse), a.need(PermManageCourse), a.identify)
+	mux("DELETE", "/kursoj/{course}", h(a.DeleteCourse), a.need(PermManageCourse), a.identify)
 
 	mux("GET", "/kursoj/{course}/eroj", h(a.GetLessons), a.identify)
-	mux("POST", "/kursoj/{course}/eroj", h(a.PostLessons), a.identify)
+	mux("POST", "/kursoj/{course}/eroj", h(a.PostLessons), a.need(PermManageCourse), a.identify)
+	mux("PUT", "/kursoj/{course}/ordo", h(a.PutLessonOrder), a.need(PermManageCourse), a.identify)
 	mux("GET", "/kursoj/{course}/eroj/{lesson}", h(a.GetLesson), a.identify)
+	mux("PATCH", "/kursoj/{course}/eroj/{lesson}", h(a.PatchLesson), a.need(PermManageCourse), a.identify)
+	mux("DELETE", "/kursoj/{course}/eroj/{lesson}", h(a.DeleteLesson), a.need(PermManageCourse), a.identify)
+
+	mux("GET", "/kursoj/{course}/eroj/{lesson}/hejmtaskoj", h(a.GetHomeworksForCoursePart), a.need(PermViewProgress), a.identify)
+
+	mux("GET", "/kursoj/{course}/eroj/{lesson}/dosieroj", h(a.GetLessonFiles), a.need(PermViewCourse), a.identify)
+	mux("POST", "/kursoj/{course}/eroj/{lesson}/dosieroj", h(a.PostLessonFile), a.need(PermManageCourse), a.identify)
+	mux("GET", "/kursoj/{course}/eroj/{lesson}/dosieroj/{file}", h(a.GetLessonFile), a.need(PermViewCourse), a.identify)
+	mux("DELETE", "/kursoj/{course}/eroj/{lesson}/dosieroj/{file}", h(a.DeleteLessonFile), a.need(PermManageCourse), a.identify)
+
+	mux("GET", "/kursoj/{course}/eroj/{lesson}/taskoj", h(a.GetAssignments), a.identify)
+	mux("POST", "/kursoj/{course}/eroj/{lesson}/taskoj", h(a.PostAssignment), a.need(PermManageCourse), a.identify)
+	mux("GET", "/kursoj/{course}/eroj/{lesson}/taskoj/{assignment}", h(a.GetAssignment), a.identify)
+	mux("PATCH", "/kursoj/{course}/eroj/{lesson}/taskoj/{assignment}", h(a.PatchAssignment), a.need(PermManageCourse), a.identify)
+	mux("DELETE", "/kursoj/{course}/eroj/{lesson}/taskoj/{assignment}", h(a.DeleteAssignment), a.need(PermManageCourse), a.identify)
+
+	mux("GET", "/kursoj/{course}/instruistoj", h(a.GetTeachers), a.identify)
+	mux("POST", "/kursoj/{course}/instruistoj", h(a.PostTeachers), a.need(PermManageStaff), a.identify)
+	mux("DELETE", "/kursoj/{course}/instruistoj/{teacher}", h(a.DeleteTeacher), a.need(PermManageStaff), a.identify)
+
+	mux("GET", "/kursoj/{course}/observantoj", h(a.GetObservers), a.identify)
+	mux("POST", "/kursoj/{course}/observantoj", h(a.PostObservers), a.need(PermManageStaff), a.identify)
+	mux("DELETE", "/kursoj/{course}/observantoj/{observer}", h(a.DeleteObserver), a.need(PermManageStaff), a.identify)
+
+	mux("GET", "/kursoj/{course}/rubrikoj", h(a.GetRubrics), a.identify)
+	mux("POST", "/kursoj/{course}/rubrikoj", h(a.PostRubric), a.need(PermManageCourse), a.identify)
+	mux("GET", "/kursoj/{course}/rubrikoj/{rubric}", h(a.GetRubric), a.identify)
+	mux("DELETE", "/kursoj/{course}/rubrikoj/{rubric}", h(a.DeleteRubric), a.need(PermManageCourse), a.identify)
+	mux("GET", "/kursoj/{course}/notoj", h(a.GetGradebook), a.need(PermViewProgress), a.identify)
+
+	mux("GET", "/kursoj/{course}/kodo", h(a.GetJoinCode), a.need(PermManageCourse), a.identify)
+	mux("POST", "/kursoj/{course}/kodo", h(a.PostJoinCode), a.need(PermManageCourse), a.identify)
+	mux("DELETE", "/kursoj/{course}/kodo", h(a.DeleteJoinCode), a.need(PermManageCourse), a.identify)
+	mux("POST", "/kursoj/{course}/aligxi", h(a.PostJoin), a.identify)
+
+	mux("POST", "/kursoj/{course}/lernantoj", h(a.PostLearners), a.need(PermManageCourse), a.identify)
+	mux("GET", "/kursoj/{course}/lernantoj", h(a.GetLearners), a.need(PermViewCourse), a.identify)
+	mux("GET", "/kursoj/{course}/lernantoj/{learner}", h(a.GetLearner), a.inCourse(), a.identify)
+	mux("DELETE", "/kursoj/{course}/lernantoj/{learner}", h(a.DeleteLearner), a.inCourse(), a.identify)
+	mux("POST", "/kursoj/{course}/lernantoj/{learner}/stato", h(a.PostLearnerStatus), a.inCourse(), a.identify)
+
+	mux("GET", "/uzantoj/{user}/kursoj", h(a.GetCoursesForUser), a.needOrSelf(PermManageUsers), a.identify)
+
+	mux("POST", "/uzantoj/{user}/hejmtaskoj", h(a.PostHomework), a.needOrSelf(PermManageUsers), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj", h(a.GetHomeworksForUser), a.needOrSelf(PermManageUsers), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}", h(a.GetHomework), a.identify)
+	mux("PATCH", "/uzantoj/{user}/hejmtaskoj/{homework}", h(a.PatchHomework), a.needOrSelf(PermManageUsers), a.identify)
+	mux("POST", "/uzantoj/{user}/hejmtaskoj/{homework}/stato", h(a.PostHomeworkStatus), a.identify)
 
-	mux("GET", "/kursoj/{course}/eroj/{lesson}/hejmtaskoj", h(a.GetHomeworksForCoursePart), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/versioj", h(a.GetRevisions), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/versioj/diferenco", h(a.GetRevisionDiff), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/versioj/{revision}", h(a.GetRevision), a.identify)
 
-	mux("POST", "/kursoj/{course}/lernantoj", h(a.PostLearners), a.forAdmin, a.identify)
-	mux("GET", "/kursoj/{course}/lernantoj", h(a.GetLearners), a.identify)
-	mux("GET", "/kursoj/{course}/lernantoj/{learner}", h(a.GetLearner), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/korektoj", h(a.GetCorrections), a.identify)
+	mux("POST", "/uzantoj/{user}/hejmtaskoj/{homework}/korektoj", h(a.PostCorrection), a.identify)
+	mux("DELETE", "/uzantoj/{user}/hejmtaskoj/{homework}/korektoj/{correction}", h(a.DeleteCorrection), a.identify)
 
-	mux("GET", "/uzantoj/{user}/kursoj", h(a.GetCoursesForUser), a.forAdminOrSelf, a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/dosieroj", h(a.GetHomeworkFiles), a.identify)
+	mux("POST", "/uzantoj/{user}/hejmtaskoj/{homework}/dosieroj", h(a.PostHomeworkFile), a.needOrSelf(PermManageUsers), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/dosieroj/{file}", h(a.GetHomeworkFile), a.identify)
+	mux("DELETE", "/uzantoj/{user}/hejmtaskoj/{homework}/dosieroj/{file}", h(a.DeleteHomeworkFile), a.needOrSelf(PermManageUsers), a.identify)
 
-	mux("POST", "/uzantoj/{user}/hejmtaskoj", h(a.PostHomework), a.forAdminOrSelf, a.identify)
-	mux("GET", "/uzantoj/{user}/hejmtaskoj", h(a.GetHomeworksForUser), a.forAdminOrSelf, a.identify)
-	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}", h(a.GetHomework), a.identify)
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/komentoj", h(a.GetComments), a.identify)
+	mux("POST", "/uzantoj/{user}/hejmtaskoj/{homework}/komentoj", h(a.PostComment), a.identify)
+	mux("PATCH", "/uzantoj/{user}/hejmtaskoj/{homework}/komentoj/{comment}", h(a.PatchComment), a.identify)
+	mux("DELETE", "/uzantoj/{user}/hejmtaskoj/{homework}/komentoj/{comment}", h(a.DeleteComment), a.identify)
+
+	mux("GET", "/uzantoj/{user}/hejmtaskoj/{homework}/notoj", h(a.GetGrades), a.identify)
+	mux("PUT", "/uzantoj/{user}/hejmtaskoj/{homework}/notoj", h(a.PutGrades), a.identify)
 }
 
+// maxBodySize is the biggest JSON body, which is room for the longest homework and more.
+const maxBodySize = 1 << 20
+
 type ctxKey int
 
-const ctxKeyUser ctxKey = 1
+const (
+	ctxKeyUser     ctxKey = 1
+	ctxKeyAccess   ctxKey = 2
+	ctxKeyAPIToken ctxKey = 3
+)
 
 func (a *front) identify(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -61,19 +164,28 @@ func (a *front) identify(next http.HandlerFunc) http.HandlerFunc {
 		type seancfn func() (*User, error)
 
 		tryCookie := func() (*User, error) {
-			if sessionCookie, er := r.Cookie("Seanco"); er == nil {
+			if sessionCookie, er := r.Cookie(a.cookie.Name); er == nil {
 				sessionID := sessionCookie.Value
 
-				user, er := a.ident.getSessionUser(sessionID)
+				session, renewed, er := a.ident.getSession(ctx, sessionID)
 				if er != nil {
 					if errors.Is(er, ErrNoSession) {
 						return nil, nil
 					}
 
-					return nil, lib.ErrHTTPUnauthorized
+					return nil, er
 				}
 
-				return user, nil
+				// only good for giving a second factor
+				if session.Pending {
+					return nil, nil
+				}
+
+				if renewed {
+					http.SetCookie(w, a.sessionCookie(sessionID, session.ExpiresAt))
+				}
+
+				return &session.UserX, nil
 			}
 
 			return nil, nil
@@ -81,17 +193,18 @@ func (a *front) identify(next http.HandlerFunc) http.HandlerFunc {
 
 		tryHeader := func() (*User, error) {
 			if email, password, ok := r.BasicAuth(); ok {
-				user, er := a.back.getUserByEmail(ctx, email)
+				user, er := a.checkLogin(ctx, r, email, password)
 				if er != nil {
-					if errors.Is(er, rel.ErrNotFound) {
+					if errors.Is(er, rel.ErrNotFound) || errors.Is(er, ErrUnverified) {
 						return nil, lib.ErrHTTPUnauthorized
 					}
 
 					return nil, er
 				}
 
-				if user.Password != password {
-					return nil, lib.ErrHTTPUnauthorized
+				// there is nowhere to give a second factor, so scripts must use API tokens
+				if user.TOTPEnabledAt != nil {
+					return nil, fmt.Errorf("%w: uzu API-ĵetonon", lib.ErrHTTPUnauthorized)
 				}
 
 				return &user, nil
@@ -100,17 +213,42 @@ func (a *front) identify(next http.HandlerFunc) http.HandlerFunc {
 			return nil, nil
 		}
 
-		tryBasic := func() (*User, error) { return nil, nil }
+		tryBearer := func() (*User, error) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				return nil, nil
+			}
+
+			at, er := a.back.useAPIToken(ctx, token)
+			if er != nil {
+				if errors.Is(er, rel.ErrNotFound) {
+					return nil, lib.ErrHTTPUnauthorized
+				}
+
+				return nil, er
+			}
+
+			if !tokenAllows(splitScopes(at.Scopes), r.Method) {
+				return nil, fmt.Errorf("%w: ĵetono ne rajtas", lib.ErrHTTPForbidden)
+			}
+
+			// so that handlers can tell
+			ctx = context.WithValue(ctx, ctxKeyAPIToken, &at)
+
+			return &at.UserX, nil
+		}
 
-		for _, f := range []seancfn{tryCookie, tryHeader, tryBasic} {
+		for _, f := range []seancfn{tryCookie, tryHeader, tryBearer} {
 			user, er := f()
 			if er != nil {
-				lib.SendHTTPError(w, 0, er)
+				sendLoginError(w, er)
 				return
 			}
 
 			if user != nil {
-				ctx1 := context.WithValue(r.Context(), ctxKeyUser, user)
+				user = withoutUnsafeAdmin(user, a.twoFactor.RequireAdmins)
+
+				ctx1 := context.WithValue(ctx, ctxKeyUser, user)
 				r1 := r.WithContext(ctx1)
 
 				a.log(ctx).Debug("auth", "user", user.ID)
@@ -125,34 +263,6 @@ func (a *front) identify(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (a *front) forAdmin(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		u := a.userFromContext(r.Context())
-
-		if !u.Admin {
-			lib.SendHTTPError(w, 0, lib.ErrHTTPForbidden)
-			return
-		}
-
-		next(w, r)
-	}
-}
-
-func (a *front) forAdminOrSelf(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.PathValue("user")
-
-		u := a.userFromContext(r.Context())
-
-		if !u.Admin && u.ID != DBID(userID) {
-			lib.SendHTTPError(w, 0, lib.ErrHTTPForbidden)
-			return
-		}
-
-		next(w, r)
-	}
-}
-
 func (a *front) Login(ctx context.Context, r *http.Request) any {
 	type loginReq struct {
 		Email    string `json:"retpoŝto"`
@@ -164,41 +274,75 @@ func (a *front) Login(ctx context.Context, r *http.Request) any {
 		return err
 	}
 
-	user, err := a.back.getUserByLogin(ctx, req.Email, req.Password)
+	user, err := a.checkLogin(ctx, r, req.Email, req.Password)
 	if err != nil {
 		if errors.Is(err, rel.ErrNotFound) {
 			return lib.HTTPResponse{Status: http.StatusForbidden}
 		}
+		if errors.Is(err, ErrUnverified) {
+			return fmt.Errorf("%w: %w", lib.ErrHTTPForbidden, err)
+		}
+		var tm errTooManyTries
+		if errors.As(err, &tm) {
+			return tooManyTriesResponse(tm)
+		}
 		return err
 	}
 
-	sID, err := a.ident.putSession(user)
+	// never carry on with a session from before login
+	if cookie, err := r.Cookie(a.cookie.Name); err == nil {
+		if err := a.ident.deleteSession(ctx, cookie.Value); err != nil {
+			return err
+		}
+	}
+
+	if user.TOTPEnabledAt != nil {
+		session, token, err := a.ident.putPendingSession(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return lib.HTTPResponse{
+			Status:  http.StatusAccepted,
+			Cookies: []*http.Cookie{a.sessionCookie(token, session.ExpiresAt)},
+			Data: EntityResponse{
+				Message: "bezonas kodon",
+				Entity: UserJSON{
+					ID:        user.ID,
+					TwoFactor: true,
+				},
+			},
+		}
+	}
+
+	return a.newSessionResponse(ctx, user)
+}
+
+// newSessionResponse logs a user in, once they have given everything needed.
+func (a *front) newSessionResponse(ctx context.Context, user User) any {
+	session, token, err := a.ident.putSession(ctx, user)
 	if err != nil {
 		return err
 	}
 
-	sessionCookie := &http.Cookie{
-		Name:    "Seanco",
-		Value:   sID,
-		Path:    "/",
-		Expires: time.Now().Add(24 * time.Hour),
-	}
+	user1 := withoutUnsafeAdmin(&user, a.twoFactor.RequireAdmins)
 
 	return lib.HTTPResponse{
-		Cookies: []*http.Cookie{sessionCookie},
+		Cookies: []*http.Cookie{a.sessionCookie(token, session.ExpiresAt)},
 		Data: EntityResponse{
-			Message: "seanco " + sID,
+			Message: "seanco",
 			Entity: UserJSON{
-				ID:    user.ID,
-				Name:  user.Name,
-				Admin: user.Admin,
+				ID:        user1.ID,
+				Name:      user1.Name,
+				Admin:     user1.Admin,
+				TwoFactor: user1.TOTPEnabledAt != nil,
 			},
 		},
 	}
 }
 
 func (a *front) Logout(ctx context.Context, r *http.Request) any {
-	cookie, err := r.Cookie("Seanco")
+	cookie, err := r.Cookie(a.cookie.Name)
 	if err != nil {
 		if errors.Is(err, http.ErrNoCookie) {
 			return lib.HTTPResponse{}
@@ -207,24 +351,31 @@ func (a *front) Logout(ctx context.Context, r *http.Request) any {
 		return err
 	}
 
-	sID := cookie.Value
-
-	a.ident.deleteSession(sID)
-
-	sessionCookie := &http.Cookie{
-		Name:    "Seanco",
-		Path:    "/",
-		Expires: time.Time{},
+	if err := a.ident.deleteSession(ctx, cookie.Value); err != nil {
+		return err
 	}
 
 	return lib.HTTPResponse{
-		Cookies: []*http.Cookie{sessionCookie},
+		Cookies: []*http.Cookie{a.sessionCookie("", time.Time{})},
 		Data: EntityResponse{
-			Message: "seanco " + sID,
+			Message: "seanco finita",
 		},
 	}
 }
 
+// sessionCookie fills in the configured cookie template. An empty value deletes the cookie.
+func (a *front) sessionCookie(value string, expires time.Time) *http.Cookie {
+	cookie := a.cookie
+	cookie.Value = value
+	cookie.Expires = expires
+
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+
+	return &cookie
+}
+
 func (a *front) userFromContext(ctx context.Context) *User {
 	return ctx.Value(ctxKeyUser).(*User)
 }
@@ -235,9 +386,10 @@ func (a *front) AboutMe(ctx context.Context, r *http.Request) any {
 	return EntityResponse{
 		Message: "uzanto",
 		Entity: UserJSON{
-			ID:    user.ID,
-			Name:  user.Name,
-			Admin: user.Admin,
+			ID:        user.ID,
+			Name:      user.Name,
+			Admin:     user.Admin,
+			TwoFactor: user.TOTPEnabledAt != nil,
 		},
 	}
 }
@@ -266,10 +418,18 @@ func (a *front) PostUsers(ctx context.Context, r *http.Request) any {
 		return err
 	}
 
+	if err := checkNewPassword(user0.Password); err != nil {
+		return err
+	}
+
+	// an admin vouches for the email
+	now := time.Now()
+
 	user1, err := a.back.putUser(ctx, User{
-		Name:     user0.Name,
-		Email:    user0.Email,
-		Password: user0.Password,
+		Name:       user0.Name,
+		Email:      user0.Email,
+		Password:   user0.Password,
+		VerifiedAt: &now,
 	})
 	if err != nil {
 		return err
@@ -298,15 +458,202 @@ func (a *front) GetUser(ctx context.Context, r *http.Request) any {
 	}
 }
 
+func (a *front) PatchUser(ctx context.Context, r *http.Request) any {
+	userID := r.PathValue("user")
+	if userID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	type userPatch struct {
+		Name  *string `json:"nomo"`
+		Email *string `json:"retpoŝto"`
+		Admin *bool   `json:"admina"`
+		// Password is needed for users to change their own email, since the email is how
+		// they would get back in.
+		Password string `json:"pasvorto"`
+	}
+
+	me := a.userFromContext(ctx)
+
+	patch, err := DecodeBody(r, &userPatch{})
+	if err != nil {
+		return err
+	}
+
+	if patch.Admin != nil && !a.allowed(ctx, PermManageUsers) {
+		return lib.ErrHTTPForbidden
+	}
+
+	user0, err := a.back.getUser(ctx, DBID(userID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	var mutates []rel.Mutate
+
+	if patch.Name != nil {
+		if *patch.Name == "" {
+			return fmt.Errorf("%w: mankas nomo", lib.ErrHTTPBadRequest)
+		}
+		mutates = append(mutates, rel.Set("name", *patch.Name))
+	}
+
+	if patch.Email != nil {
+		if *patch.Email == "" {
+			return fmt.Errorf("%w: mankas retpoŝto", lib.ErrHTTPBadRequest)
+		}
+		if err := checkEmail(*patch.Email); err != nil {
+			return err
+		}
+		if user0.ID == me.ID && *patch.Email != user0.Email {
+			if ok, _ := lib.CheckPassword(user0.Password, patch.Password); !ok {
+				return fmt.Errorf("%w: malĝusta pasvorto", lib.ErrHTTPForbidden)
+			}
+		}
+		mutates = append(mutates, rel.Set("email", *patch.Email))
+	}
+
+	if patch.Admin != nil {
+		mutates = append(mutates, rel.Set("admin", *patch.Admin))
+	}
+
+	if len(mutates) == 0 {
+		return fmt.Errorf("%w: nenio ŝanĝota", lib.ErrHTTPBadRequest)
+	}
+
+	user1, err := a.back.updateUser(ctx, user0, mutates...)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "ŝanĝita uzanto",
+		Entity:  apiFromUser(user1),
+	}
+}
+
+func (a *front) DeleteUser(ctx context.Context, r *http.Request) any {
+	userID := r.PathValue("user")
+	if userID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	me := a.userFromContext(ctx)
+
+	if me.ID == DBID(userID) {
+		return fmt.Errorf("%w: ne eblas forigi sin mem", lib.ErrHTTPBadRequest)
+	}
+
+	user, err := a.back.getUser(ctx, DBID(userID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	if err := a.back.deleteUser(ctx, user); err != nil {
+		return err
+	}
+
+	if err := a.ident.deleteUserSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita uzanto",
+		Entity:  UserJSON{ID: user.ID},
+	}
+}
+
+// PostPassword lets a user change their own password, given the old one. All their sessions
+// end, and they get a new one.
+func (a *front) PostPassword(ctx context.Context, r *http.Request) any {
+	userID := r.PathValue("user")
+	if userID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	me := a.userFromContext(ctx)
+
+	if me.ID != DBID(userID) {
+		return lib.ErrHTTPForbidden
+	}
+
+	type passwordReq struct {
+		Old string `json:"malnova"`
+		New string `json:"nova"`
+	}
+
+	req, err := DecodeBody(r, &passwordReq{})
+	if err != nil {
+		return err
+	}
+
+	if err := checkNewPassword(req.New); err != nil {
+		return err
+	}
+
+	user0, err := a.back.getUser(ctx, me.ID)
+	if err != nil {
+		return err
+	}
+
+	if ok, _ := lib.CheckPassword(user0.Password, req.Old); !ok {
+		return fmt.Errorf("%w: malĝusta pasvorto", lib.ErrHTTPForbidden)
+	}
+
+	user1, err := a.back.setUserPassword(ctx, user0, req.New)
+	if err != nil {
+		return err
+	}
+
+	if err := a.ident.deleteUserSessions(ctx, user1.ID); err != nil {
+		return err
+	}
+
+	session, token, err := a.ident.putSession(ctx, user1)
+	if err != nil {
+		return err
+	}
+
+	return lib.HTTPResponse{
+		Cookies: []*http.Cookie{a.sessionCookie(token, session.ExpiresAt)},
+		Data: EntityResponse{
+			Message: "ŝanĝita pasvorto",
+			Entity:  apiFromUser(user1),
+		},
+	}
+}
+
+const minPasswordLength = 8
+
+func checkNewPassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return fmt.Errorf("%w: pasvorto tro mallonga", lib.ErrHTTPBadRequest)
+	}
+
+	return nil
+}
+
 func apiFromUser(in User) UserJSON {
 	return UserJSON{
-		ID:   in.ID,
-		Name: in.Name,
+		ID:        in.ID,
+		Name:      in.Name,
+		Email:     in.Email,
+		Admin:     in.Admin,
+		TwoFactor: in.TOTPEnabledAt != nil,
+		Locked:    in.LockedUntil != nil && time.Now().Before(*in.LockedUntil),
 	}
 }
 
 func (a *front) GetCourses(ctx context.Context, r *http.Request) any {
-	courses, err := a.back.listCourses(ctx)
+	archived := r.URL.Query().Get("arkivitaj") == "true"
+
+	courses, err := a.back.listCourses(ctx, archived)
 	if err != nil {
 		return err
 	}
@@ -336,11 +683,16 @@ func (a *front) PostCourses(ctx context.Context, r *http.Request) any {
 		owner = user.ID
 	}
 
+	if course0.MaxLearners < 0 {
+		return fmt.Errorf("%w: malbona maks_lernantoj", lib.ErrHTTPBadRequest)
+	}
+
 	course1, err := a.back.putCourse(ctx, Course{
-		OwnerID: owner,
-		Name:    course0.Name,
-		About:   course0.About,
-		Time:    course0.Time,
+		OwnerID:     owner,
+		Name:        course0.Name,
+		About:       course0.About,
+		Time:        course0.Time,
+		MaxLearners: course0.MaxLearners,
 	})
 	if err != nil {
 		return err
@@ -379,114 +731,420 @@ func apiFromCourse(in Course) CourseJSON {
 		Name:  in.Name,
 		About: in.About,
 		Time:  in.Time,
+
+		MaxLearners: in.MaxLearners,
+
+		Archived: in.ArchivedAt != nil,
 	}
 }
 
-func (a *front) GetLessons(ctx context.Context, r *http.Request) any {
-	courseID := r.PathValue("course")
-	if courseID == "" {
-		return lib.ErrHTTPNotFound
+func (a *front) PatchCourse(ctx context.Context, r *http.Request) any {
+	type coursePatch struct {
+		Owner    *UserJSON  `json:"posedanto"`
+		Name     *string    `json:"nomo"`
+		About    *string    `json:"pri"`
+		Time     *time.Time `json:"kiamo"`
+		Archived *bool      `json:"arkivita"`
+
+		MaxLearners *int `json:"maks_lernantoj"`
 	}
 
-	lessons, err := a.back.getLessonsForCourse(ctx, DBID(courseID))
+	course0 := a.courseFromContext(ctx)
+
+	patch, err := DecodeBody(r, &coursePatch{})
 	if err != nil {
 		return err
 	}
 
-	out := make([]LessonJSON, 0, len(lessons))
+	var mutates []rel.Mutate
 
-	for _, k := range lessons {
-		out = append(out, apiFromLesson(k))
+	if patch.Owner != nil {
+		if !a.allowed(ctx, PermManageUsers) {
+			return lib.ErrHTTPForbidden
+		}
+		if patch.Owner.ID == "" {
+			return fmt.Errorf("%w: mankas posedanto", lib.ErrHTTPBadRequest)
+		}
+		owner, err := a.back.getUser(ctx, patch.Owner.ID)
+		if err != nil {
+			if errors.Is(err, rel.ErrNotFound) {
+				return fmt.Errorf("%w: nekonata posedanto", lib.ErrHTTPBadRequest)
+			}
+			return err
+		}
+		mutates = append(mutates, rel.Set("owner", owner.ID))
 	}
 
-	return EntityResponse{
-		Message: "kurseroj de " + courseID,
-		Entity:  out,
+	if patch.Name != nil {
+		if *patch.Name == "" {
+			return fmt.Errorf("%w: mankas nomo", lib.ErrHTTPBadRequest)
+		}
+		mutates = append(mutates, rel.Set("name", *patch.Name))
 	}
-}
 
-func apiFromLesson(in Lesson) LessonJSON {
-	return LessonJSON{
-		ID: in.ID,
-		Course: CourseJSON{
-			ID: in.Course,
-		},
-		Name: in.Name,
-		Time: in.Time,
+	if patch.About != nil {
+		mutates = append(mutates, rel.Set("about", *patch.About))
 	}
-}
 
-func (a *front) PostLessons(ctx context.Context, r *http.Request) any {
-	courseID := r.PathValue("course")
-	if courseID == "" {
-		return lib.ErrHTTPNotFound
+	if patch.Time != nil {
+		mutates = append(mutates, rel.Set("time", *patch.Time))
 	}
 
-	user := a.userFromContext(ctx)
+	if patch.Archived != nil {
+		mutates = append(mutates, setArchived(*patch.Archived))
+	}
 
-	lesson0, err := DecodeBody(r, &LessonJSON{})
-	if err != nil {
-		return err
+	if patch.MaxLearners != nil {
+		if *patch.MaxLearners < 0 {
+			return fmt.Errorf("%w: malbona maks_lernantoj", lib.ErrHTTPBadRequest)
+		}
+		mutates = append(mutates, rel.Set("max_learners", *patch.MaxLearners))
 	}
 
-	if lesson0.Course.ID != "" && lesson0.Course.ID != DBID(courseID) {
-		return lib.ErrHTTPBadRequest
+	if len(mutates) == 0 {
+		return fmt.Errorf("%w: nenio ŝanĝota", lib.ErrHTTPBadRequest)
 	}
 
-	course, err := a.back.getCourse(ctx, DBID(courseID))
+	course1, err := a.back.updateCourse(ctx, course0, mutates...)
 	if err != nil {
 		return err
 	}
 
-	if course.OwnerID != user.ID && !user.Admin {
-		return lib.ErrHTTPForbidden
-	}
-
-	lesson1, err := a.back.putLesson(ctx, Lesson{
-		Course: course.ID,
-		Name:   lesson0.Name,
-		Time:   lesson0.Time,
-	})
-	if err != nil {
-		return err
+	// more room may let some in from the waitlist
+	if patch.MaxLearners != nil {
+		if _, err := a.back.promoteFromWaitlist(ctx, course1.ID); err != nil {
+			return err
+		}
 	}
 
 	return EntityResponse{
-		Message: "nova kursero",
-		Entity: LessonJSON{
-			ID:   lesson1.ID,
-			Name: lesson1.Name,
-			Course: CourseJSON{
-				ID: course.ID,
-			},
-		},
+		Message: "ŝanĝita kurso",
+		Entity:  apiFromCourse(course1),
 	}
 }
 
-func (a *front) GetLesson(ctx context.Context, r *http.Request) any {
-	return ErrUnimplemented
-}
+// DeleteCourse deletes a course. If any homework has been done, then that would be lost too,
+// so instead it fails, unless an admin forces it. Archiving is the normal way to end a course.
+func (a *front) DeleteCourse(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
 
-func (a *front) GetHomeworksForUser(ctx context.Context, r *http.Request) any {
-	userID := r.PathValue("user")
-	if userID == "" {
-		return lib.ErrHTTPNotFound
+	lessons := make([]DBID, 0, len(course.Lessons))
+	for _, l := range course.Lessons {
+		lessons = append(lessons, l.ID)
 	}
 
-	homeworks, err := a.back.getHomeworksForUser(ctx, DBID(userID))
-	if err != nil {
+	if err := a.checkNoHomework(ctx, r, lessons...); err != nil {
+		return err
+	}
+
+	if err := a.back.deleteCourse(ctx, course); err != nil {
 		return err
 	}
 
 	return EntityResponse{
-		Message: "hejmtaskoj de " + userID,
-		Entity:  homeworks,
+		Message: "forigita kurso",
+		Entity:  CourseJSON{ID: course.ID},
 	}
 }
 
-func (a *front) GetLearners(ctx context.Context, r *http.Request) any {
-	courseID := r.PathValue("course")
-	if courseID == "" {
+// checkNoHomework fails with a conflict if there is homework for any of some lessons, unless
+// someone who manages all users has asked to force.
+func (a *front) checkNoHomework(ctx context.Context, r *http.Request, lessons ...DBID) error {
+	if r.URL.Query().Get("devigi") == "true" && a.allowed(ctx, PermManageUsers) {
+		return nil
+	}
+
+	n, err := a.back.countHomeworksForLessons(ctx, lessons...)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		return fmt.Errorf("%w: ekzistas %d hejmtaskoj, do prefere arkivu", lib.ErrHTTPConflict, n)
+	}
+
+	return nil
+}
+
+func setArchived(archived bool) rel.Mutate {
+	if archived {
+		return rel.Set("archived_at", time.Now())
+	}
+
+	return rel.Set("archived_at", nil)
+}
+
+func (a *front) GetLessons(ctx context.Context, r *http.Request) any {
+	courseID := r.PathValue("course")
+	if courseID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	lessons, err := a.back.getLessonsForCourse(ctx, DBID(courseID))
+	if err != nil {
+		return err
+	}
+
+	out := make([]LessonJSON, 0, len(lessons))
+
+	for _, k := range lessons {
+		out = append(out, apiFromLesson(k))
+	}
+
+	return EntityResponse{
+		Message: "kurseroj de " + courseID,
+		Entity:  out,
+	}
+}
+
+func apiFromLesson(in Lesson) LessonJSON {
+	return LessonJSON{
+		ID: in.ID,
+		Course: CourseJSON{
+			ID: in.Course,
+		},
+		Name:  in.Name,
+		Time:  in.Time,
+		Order: in.Position,
+
+		Archived: in.ArchivedAt != nil,
+	}
+}
+
+// apiFromLessonContent shows a lesson with its content, as Markdown and as HTML.
+func apiFromLessonContent(in Lesson) (LessonJSON, error) {
+	out := apiFromLesson(in)
+
+	if in.Body == "" {
+		return out, nil
+	}
+
+	html, err := lib.RenderMarkdown(in.Body)
+	if err != nil {
+		return LessonJSON{}, err
+	}
+
+	out.Body, out.HTML = in.Body, html
+
+	return out, nil
+}
+
+func (a *front) PostLessons(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	lesson0, err := DecodeBody(r, &LessonJSON{})
+	if err != nil {
+		return err
+	}
+
+	if lesson0.Course.ID != "" && lesson0.Course.ID != course.ID {
+		return lib.ErrHTTPBadRequest
+	}
+
+	lesson1, err := a.back.putLesson(ctx, Lesson{
+		Course: course.ID,
+		Name:   lesson0.Name,
+		Time:   lesson0.Time,
+		Body:   lesson0.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := apiFromLessonContent(lesson1)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova kursero",
+		Entity:  out,
+	}
+}
+
+// courseLesson gets a lesson from the path, checking that it is really in the course, and
+// that the user takes part in the course, since lessons are what the course teaches.
+func (a *front) courseLesson(ctx context.Context, r *http.Request) (Lesson, error) {
+	courseID, lessonID := r.PathValue("course"), r.PathValue("lesson")
+	if courseID == "" || lessonID == "" {
+		return Lesson{}, lib.ErrHTTPNotFound
+	}
+
+	lesson, err := a.back.getLesson(ctx, DBID(lessonID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Lesson{}, lib.ErrHTTPNotFound
+		}
+		return Lesson{}, err
+	}
+
+	if lesson.Course != DBID(courseID) {
+		return Lesson{}, lib.ErrHTTPNotFound
+	}
+
+	ok, err := a.can(ctx, lesson.Course, PermViewCourse)
+	if err != nil {
+		return Lesson{}, err
+	}
+
+	if !ok {
+		return Lesson{}, lib.ErrHTTPForbidden
+	}
+
+	return lesson, nil
+}
+
+func (a *front) GetLesson(ctx context.Context, r *http.Request) any {
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	out, err := apiFromLessonContent(lesson)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "kursero " + string(lesson.ID),
+		Entity:  out,
+	}
+}
+
+func (a *front) PatchLesson(ctx context.Context, r *http.Request) any {
+	type lessonPatch struct {
+		Name     *string    `json:"nomo"`
+		Time     *time.Time `json:"kiamo"`
+		Body     *string    `json:"enhavo"`
+		Archived *bool      `json:"arkivita"`
+	}
+
+	lesson0, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	patch, err := DecodeBody(r, &lessonPatch{})
+	if err != nil {
+		return err
+	}
+
+	var mutates []rel.Mutate
+
+	if patch.Name != nil {
+		if *patch.Name == "" {
+			return fmt.Errorf("%w: mankas nomo", lib.ErrHTTPBadRequest)
+		}
+		mutates = append(mutates, rel.Set("name", *patch.Name))
+	}
+
+	if patch.Time != nil {
+		mutates = append(mutates, rel.Set("time", *patch.Time))
+	}
+
+	if patch.Body != nil {
+		mutates = append(mutates, rel.Set("body", *patch.Body))
+	}
+
+	if patch.Archived != nil {
+		mutates = append(mutates, setArchived(*patch.Archived))
+	}
+
+	if len(mutates) == 0 {
+		return fmt.Errorf("%w: nenio ŝanĝota", lib.ErrHTTPBadRequest)
+	}
+
+	lesson1, err := a.back.updateLesson(ctx, lesson0, mutates...)
+	if err != nil {
+		return err
+	}
+
+	out, err := apiFromLessonContent(lesson1)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "ŝanĝita kursero",
+		Entity:  out,
+	}
+}
+
+func (a *front) DeleteLesson(ctx context.Context, r *http.Request) any {
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkNoHomework(ctx, r, lesson.ID); err != nil {
+		return err
+	}
+
+	if err := a.back.deleteLesson(ctx, lesson); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita kursero",
+		Entity:  LessonJSON{ID: lesson.ID},
+	}
+}
+
+// PutLessonOrder sets the order of all lessons in a course at once.
+func (a *front) PutLessonOrder(ctx context.Context, r *http.Request) any {
+	type orderReq struct {
+		Lessons []DBID `json:"eroj"`
+	}
+
+	course := a.courseFromContext(ctx)
+
+	req, err := DecodeBody(r, &orderReq{})
+	if err != nil {
+		return err
+	}
+
+	lessons, err := a.back.setLessonOrder(ctx, course.ID, req.Lessons)
+	if err != nil {
+		return err
+	}
+
+	out := make([]LessonJSON, 0, len(lessons))
+
+	for _, l := range lessons {
+		out = append(out, apiFromLesson(l))
+	}
+
+	return EntityResponse{
+		Message: "kurseroj de " + string(course.ID),
+		Entity:  out,
+	}
+}
+
+func (a *front) GetHomeworksForUser(ctx context.Context, r *http.Request) any {
+	userID := r.PathValue("user")
+	if userID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	statuses, err := parseHomeworkStatuses(r.URL.Query().Get("stato"))
+	if err != nil {
+		return err
+	}
+
+	homeworks, err := a.back.getHomeworksForUser(ctx, DBID(userID), statuses)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "hejmtaskoj de " + userID,
+		Entity:  apiFromHomeworks(homeworks),
+	}
+}
+
+func (a *front) GetLearners(ctx context.Context, r *http.Request) any {
+	courseID := r.PathValue("course")
+	if courseID == "" {
 		return lib.ErrHTTPNotFound
 	}
 
@@ -495,16 +1153,17 @@ func (a *front) GetLearners(ctx context.Context, r *http.Request) any {
 		return err
 	}
 
+	positions := waitlistPositions(learners)
+
+	// those in the course first, then the waitlist in order
+	slices.SortStableFunc(learners, func(a, b Learner) int {
+		return positions[a.ID] - positions[b.ID]
+	})
+
 	out := make([]LearnerJSON, 0, len(learners))
 
 	for _, l := range learners {
-		out = append(out, LearnerJSON{
-			ID: l.ID,
-			User: UserJSON{
-				ID:   l.UserID,
-				Name: l.UserX.Name,
-			},
-		})
+		out = append(out, apiFromLearner(l, positions[l.ID]))
 	}
 
 	return EntityResponse{
@@ -514,41 +1173,194 @@ func (a *front) GetLearners(ctx context.Context, r *http.Request) any {
 }
 
 func (a *front) PostLearners(ctx context.Context, r *http.Request) any {
-	courseID := r.PathValue("course")
-	if courseID == "" {
-		return lib.ErrHTTPNotFound
-	}
+	course := a.courseFromContext(ctx)
 
 	learner0, err := DecodeBody(r, &LearnerJSON{})
 	if err != nil {
 		return err
 	}
 
-	if learner0.Course.ID != "" && learner0.Course.ID != DBID(courseID) {
+	if learner0.Course.ID != "" && learner0.Course.ID != course.ID {
 		return lib.ErrHTTPBadRequest
 	}
 
-	learner1, err := a.back.addUserToCourse(ctx, learner0.User.ID, DBID(courseID))
+	learner1, err := a.back.addUserToCourse(ctx, learner0.User.ID, course.ID, false)
 	if err != nil {
 		return err
 	}
 
+	return a.newLearnerResponse(ctx, learner1, course)
+}
+
+// newLearnerResponse tells someone who has just joined a course whether they are in, or where
+// they are on the waitlist.
+func (a *front) newLearnerResponse(ctx context.Context, learner Learner, course Course) any {
+	position, err := a.back.waitlistPosition(ctx, learner)
+	if err != nil {
+		return err
+	}
+
+	message := "nova lernanto"
+	if position > 0 {
+		message = "en atendovico"
+	}
+
+	out := apiFromLearner(learner, position)
+	out.Course.Name = course.Name
+
 	return EntityResponse{
-		Message: "nova lernanto",
-		Entity: LearnerJSON{
-			ID: learner1.ID,
-			User: UserJSON{
-				ID: learner1.UserID,
-			},
-			Course: CourseJSON{
-				ID: learner1.CourseID,
-			},
-		},
+		Message: message,
+		Entity:  out,
+	}
+}
+
+// courseLearner gets a learner from the path, if the user is that learner or manages the
+// course. It also says which.
+func (a *front) courseLearner(ctx context.Context, r *http.Request) (Learner, bool, error) {
+	user := a.userFromContext(ctx)
+
+	courseID, learnerID := r.PathValue("course"), r.PathValue("learner")
+	if courseID == "" || learnerID == "" {
+		return Learner{}, false, lib.ErrHTTPNotFound
+	}
+
+	learner, err := a.back.getLearner(ctx, DBID(learnerID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Learner{}, false, lib.ErrHTTPNotFound
+		}
+		return Learner{}, false, err
 	}
+
+	if learner.CourseID != DBID(courseID) {
+		return Learner{}, false, lib.ErrHTTPNotFound
+	}
+
+	if a.allowed(ctx, PermManageCourse) {
+		return learner, true, nil
+	}
+
+	if learner.UserID != user.ID {
+		return Learner{}, false, lib.ErrHTTPForbidden
+	}
+
+	return learner, false, nil
 }
 
 func (a *front) GetLearner(ctx context.Context, r *http.Request) any {
-	return ErrUnimplemented
+	learner, _, err := a.courseLearner(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	position, err := a.back.waitlistPosition(ctx, learner)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "lernanto " + string(learner.ID),
+		Entity:  apiFromLearner(learner, position),
+	}
+}
+
+// PostLearnerStatus moves a learner to another status. Learners can pause, come back and
+// leave by themselves; the rest is for those who manage the course.
+func (a *front) PostLearnerStatus(ctx context.Context, r *http.Request) any {
+	type statusReq struct {
+		Status LearnerStatus `json:"stato"`
+	}
+
+	learner0, manager, err := a.courseLearner(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	req, err := DecodeBody(r, &statusReq{})
+	if err != nil {
+		return err
+	}
+
+	if learner0.WaitingSince != nil {
+		return fmt.Errorf("%w: lernanto ankoraŭ atendas", lib.ErrHTTPConflict)
+	}
+
+	if err := checkLearnerTransition(learner0.Status, req.Status, manager); err != nil {
+		return err
+	}
+
+	learner1, err := a.back.setLearnerStatus(ctx, learner0, req.Status, a.userFromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	position, err := a.back.waitlistPosition(ctx, learner1)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "ŝanĝita lernanto",
+		Entity:  apiFromLearner(learner1, position),
+	}
+}
+
+// DeleteLearner takes a learner out of a course. Managers of the course can do this, and
+// learners can leave by themselves. The learner is only marked as dropped, so that their
+// homework stays, unless they were still waiting, or an admin forces it. Someone from the
+// waitlist takes the place.
+func (a *front) DeleteLearner(ctx context.Context, r *http.Request) any {
+	learner, manager, err := a.courseLearner(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	// really removing a learner, and not only marking them as gone, is for those who manage all users
+	force := r.URL.Query().Get("devigi") == "true" && a.allowed(ctx, PermManageUsers)
+
+	if learner.WaitingSince != nil || force {
+		if err := a.back.removeLearner(ctx, learner); err != nil {
+			return err
+		}
+
+		return EntityResponse{
+			Message: "forigita lernanto",
+			Entity:  LearnerJSON{ID: learner.ID},
+		}
+	}
+
+	if err := checkLearnerTransition(learner.Status, LearnerDropped, manager); err != nil {
+		return err
+	}
+
+	learner1, err := a.back.setLearnerStatus(ctx, learner, LearnerDropped, a.userFromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "lernanto forlasis",
+		Entity:  apiFromLearner(learner1, 0),
+	}
+}
+
+func apiFromLearner(in Learner, position int) LearnerJSON {
+	return LearnerJSON{
+		ID: in.ID,
+		User: UserJSON{
+			ID:   in.UserID,
+			Name: in.UserX.Name,
+		},
+		Course: CourseJSON{
+			ID: in.CourseID,
+		},
+		Waiting:     position,
+		Status:      in.Status,
+		EnrolledAt:  in.EnrolledAt,
+		PausedAt:    in.PausedAt,
+		CompletedAt: in.CompletedAt,
+		DroppedAt:   in.DroppedAt,
+	}
 }
 
 func (a *front) GetCoursesForUser(ctx context.Context, r *http.Request) any {
@@ -595,68 +1407,396 @@ func (a *front) PostHomework(ctx context.Context, r *http.Request) any {
 		return lib.ErrHTTPForbidden
 	}
 
-	if homework0.Text == "" {
-		return fmt.Errorf("%w: mankas teksto", lib.ErrHTTPBadRequest)
+	if homework0.Lesson.ID == "" && homework0.Assignment.ID == "" {
+		return fmt.Errorf("%w: mankas kursero aŭ tasko", lib.ErrHTTPBadRequest)
+	}
+
+	if err := checkHomeworkText(homework0.Text); err != nil {
+		return err
+	}
+
+	status := homework0.Status
+	if status == "" {
+		status = HomeworkSubmitted
 	}
 
-	homework1, err := a.back.putHomework(ctx, user.ID, homework0.Text)
+	if status != HomeworkSubmitted && status != HomeworkDraft {
+		return fmt.Errorf("%w: nova hejmtasko estu %s aŭ %s", lib.ErrHTTPBadRequest, HomeworkDraft, HomeworkSubmitted)
+	}
+
+	var assignment *Assignment
+
+	if homework0.Assignment.ID != "" {
+		assignment1, err := a.back.getAssignment(ctx, homework0.Assignment.ID)
+		if err != nil {
+			if errors.Is(err, rel.ErrNotFound) {
+				return fmt.Errorf("%w: nekonata tasko", lib.ErrHTTPBadRequest)
+			}
+			return err
+		}
+
+		if homework0.Lesson.ID != "" && homework0.Lesson.ID != assignment1.LessonID {
+			return fmt.Errorf("%w: tasko ne de tiu kursero", lib.ErrHTTPBadRequest)
+		}
+
+		homework0.Lesson.ID = assignment1.LessonID
+		assignment = &assignment1
+	}
+
+	lesson, err := a.back.getLesson(ctx, homework0.Lesson.ID)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("%w: nekonata kursero", lib.ErrHTTPBadRequest)
+		}
+		return err
+	}
+
+	if lesson.ArchivedAt != nil {
+		return fmt.Errorf("%w: kursero arkivita", lib.ErrHTTPConflict)
+	}
+
+	learner, err := a.back.getLearnerForCourse(ctx, user.ID, lesson.Course)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("%w: ne lernanto de la kurso", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	learner.UserX = *user
+
+	homework := Homework{
+		LearnerX: learner,
+		LessonX:  lesson,
+		Text:     homework0.Text,
+		Status:   status,
+	}
+
+	if assignment != nil {
+		homework.AssignmentID = &assignment.ID
+
+		// drafts can be anything, until submitted
+		if status == HomeworkSubmitted {
+			homework.Late, err = checkSubmission(*assignment, homework.Text, time.Now(), true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	homework1, err := a.back.putHomework(ctx, homework)
 	if err != nil {
 		return err
 	}
 
 	return EntityResponse{
 		Message: "nova hejmtasko",
-		Entity: HomeworkJSON{
-			ID: homework1.ID,
-			Learner: UserJSON{
-				ID: homework1.LearnerID,
-			},
-			Text: homework1.Text,
-		},
+		Entity:  apiFromHomework(homework1),
 	}
 }
 
-func (a *front) GetHomework(ctx context.Context, r *http.Request) any {
-	homeworkID := r.PathValue("homework")
+// visibleHomework gets a homework from the path, if the user may see it, which is for the
+// learner who did it, and anyone who may see the progress of the course.
+func (a *front) visibleHomework(ctx context.Context, r *http.Request) (Homework, error) {
+	userID, homeworkID := r.PathValue("user"), r.PathValue("homework")
+	if userID == "" || homeworkID == "" {
+		return Homework{}, lib.ErrHTTPNotFound
+	}
+
+	user := a.userFromContext(ctx)
 
 	homework, err := a.back.getHomework(ctx, DBID(homeworkID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Homework{}, lib.ErrHTTPNotFound
+		}
+		return Homework{}, err
+	}
+
+	if homework.LearnerX.UserID != DBID(userID) {
+		return Homework{}, lib.ErrHTTPNotFound
+	}
+
+	if user.ID == homework.LearnerX.UserID {
+		return homework, nil
+	}
+
+	// drafts are private
+	if homework.Status == HomeworkDraft {
+		return Homework{}, lib.ErrHTTPNotFound
+	}
+
+	ok, err := a.can(ctx, homework.LessonX.Course, PermViewProgress)
+	if err != nil {
+		return Homework{}, err
+	}
+
+	if !ok {
+		return Homework{}, lib.ErrHTTPForbidden
+	}
+
+	return homework, nil
+}
+
+// writableHomework gets a homework from the path, if the user may do anything with it, which
+// is for the learner who did it, and anyone who may correct it. That is only while the
+// learner is still in the course. After they leave, their homework can only be read.
+func (a *front) writableHomework(ctx context.Context, r *http.Request) (Homework, error) {
+	user := a.userFromContext(ctx)
+
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return Homework{}, err
+	}
+
+	if homework.LearnerX.UserID != user.ID {
+		ok, err := a.can(ctx, homework.LessonX.Course, PermReview)
+		if err != nil {
+			return Homework{}, err
+		}
+
+		if !ok {
+			return Homework{}, lib.ErrHTTPForbidden
+		}
+	}
+
+	if err := checkLearnerActive(homework.LearnerX); err != nil {
+		return Homework{}, err
+	}
+
+	return homework, nil
+}
+
+func (a *front) GetHomework(ctx context.Context, r *http.Request) any {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	corrections, err := a.visibleCorrections(ctx, homework)
+	if err != nil {
+		return err
+	}
+
+	texts, err := a.back.revisionTexts(ctx, homework)
+	if err != nil {
+		return err
+	}
+
+	out := apiFromHomework(homework)
+	out.Corrections = apiFromCorrections(texts, corrections)
+
+	return EntityResponse{
+		Message: "hejmtasko " + string(homework.ID),
+		Entity:  out,
+	}
+}
+
+// PatchHomework lets a learner change the text of a draft.
+func (a *front) PatchHomework(ctx context.Context, r *http.Request) any {
+	type homeworkPatch struct {
+		Text string `json:"teksto"`
+	}
+
+	user := a.userFromContext(ctx)
+
+	homework0, err := a.writableHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if homework0.LearnerX.UserID != user.ID {
+		return lib.ErrHTTPForbidden
+	}
+
+	patch, err := DecodeBody(r, &homeworkPatch{})
+	if err != nil {
+		return err
+	}
+
+	if err := checkHomeworkText(patch.Text); err != nil {
+		return err
+	}
+
+	homework1, err := a.back.updateHomeworkText(ctx, homework0, patch.Text)
 	if err != nil {
 		return err
 	}
 
 	return EntityResponse{
-		Message: "homework " + homeworkID,
-		Entity: HomeworkJSON{
-			ID: homework.ID,
-			Learner: UserJSON{
-				ID: homework.LearnerID,
+		Message: "ŝanĝita hejmtasko",
+		Entity:  apiFromHomework(homework1),
+	}
+}
+
+// checkResubmission checks homework that is being submitted again against its assignment, if
+// it has one.
+func (a *front) checkResubmission(ctx context.Context, homework Homework, text string) (bool, error) {
+	if homework.AssignmentID == nil {
+		return false, nil
+	}
+
+	if text == "" {
+		text = homework.Text
+	}
+
+	assignment, err := a.back.getAssignment(ctx, *homework.AssignmentID)
+	if err != nil {
+		return false, err
+	}
+
+	return checkSubmission(assignment, text, time.Now(), homework.SubmittedAt == nil)
+}
+
+// PostHomeworkStatus moves homework through its lifecycle. The learner submits, and
+// teachers claim, correct and return.
+func (a *front) PostHomeworkStatus(ctx context.Context, r *http.Request) any {
+	type statusReq struct {
+		Status HomeworkStatus `json:"stato"`
+		// Text can come with a resubmission
+		Text string `json:"teksto"`
+	}
+
+	user := a.userFromContext(ctx)
+
+	homework0, err := a.writableHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	req, err := DecodeBody(r, &statusReq{})
+	if err != nil {
+		return err
+	}
+
+	// anyone else who can change it may correct it
+	teacher := homework0.LearnerX.UserID != user.ID
+
+	if err := checkHomeworkTransition(homework0.Status, req.Status, teacher); err != nil {
+		return err
+	}
+
+	var homework1 Homework
+
+	if req.Status == HomeworkSubmitted && !teacher {
+		if req.Text != "" {
+			if err := checkHomeworkText(req.Text); err != nil {
+				return err
+			}
+		}
+
+		late, err := a.checkResubmission(ctx, homework0, req.Text)
+		if err != nil {
+			return err
+		}
+
+		homework1, err = a.back.submitHomework(ctx, homework0, req.Text, late, user)
+		if err != nil {
+			return err
+		}
+	} else if req.Text != "" {
+		return fmt.Errorf("%w: teksto nur kun ensendo", lib.ErrHTTPBadRequest)
+	} else {
+		// the owner can take over from a teacher who is gone
+		var override bool
+		override, err = a.can(ctx, homework0.LessonX.Course, PermManageStaff)
+		if err != nil {
+			return err
+		}
+
+		homework1, err = a.back.setHomeworkStatus(ctx, homework0, req.Status, user, override)
+	}
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "hejmtasko " + string(req.Status),
+		Entity:  apiFromHomework(homework1),
+	}
+}
+
+func apiFromHomework(in Homework) HomeworkJSON {
+	var reviewer UserJSON
+	if in.ReviewerID != nil {
+		reviewer.ID = *in.ReviewerID
+	}
+
+	var assignment AssignmentJSON
+	if in.AssignmentID != nil {
+		assignment.ID = *in.AssignmentID
+	}
+
+	return HomeworkJSON{
+		ID: in.ID,
+		Learner: UserJSON{
+			ID:   in.LearnerX.UserID,
+			Name: in.LearnerX.UserX.Name,
+		},
+		Lesson: LessonJSON{
+			ID:   in.LessonID,
+			Name: in.LessonX.Name,
+			Course: CourseJSON{
+				ID: in.LessonX.Course,
 			},
 		},
+		Text: in.Text,
+
+		Assignment: assignment,
+		Late:       in.Late,
+
+		Status:      in.Status,
+		Reviewer:    reviewer,
+		SubmittedAt: in.SubmittedAt,
+		ClaimedAt:   in.ClaimedAt,
+		CorrectedAt: in.CorrectedAt,
+		ReturnedAt:  in.ReturnedAt,
+	}
+}
+
+func apiFromHomeworks(in []Homework) []HomeworkJSON {
+	out := make([]HomeworkJSON, 0, len(in))
+
+	for _, h := range in {
+		out = append(out, apiFromHomework(h))
 	}
+
+	return out
 }
 
 func (a *front) GetHomeworksForCoursePart(ctx context.Context, r *http.Request) any {
-	course, lesson := r.PathValue("course"), r.PathValue("lesson")
-	if course == "" || lesson == "" {
-		return lib.ErrHTTPNotFound
+	course := a.courseFromContext(ctx)
+
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
 	}
 
-	homeworks, err := a.back.getHomeworksForLesson(ctx, DBID(course), DBID(lesson))
+	statuses, err := parseHomeworkStatuses(r.URL.Query().Get("stato"))
+	if err != nil {
+		return err
+	}
+
+	homeworks, err := a.back.getHomeworksForLesson(ctx, course.ID, lesson.ID, statuses)
 	if err != nil {
 		return err
 	}
 
 	return EntityResponse{
-		Message: "hejmtasko pri " + lesson,
-		Entity:  homeworks,
+		Message: "hejmtasko pri " + string(lesson.ID),
+		Entity:  apiFromHomeworks(homeworks),
 	}
 }
 
 func DecodeBody[T any](r *http.Request, t *T) (*T, error) {
-	dec := json.NewDecoder(r.Body)
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize))
 
 	err := dec.Decode(t)
 	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return t, fmt.Errorf("%w: korpo tro granda", lib.ErrHTTPTooLarge)
+		}
 		return t, err
 	}
 
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// apiTokenFromContext gets the API token that the request came with, if it did.
+func (a *front) apiTokenFromContext(ctx context.Context) *APIToken {
+	at, _ := ctx.Value(ctxKeyAPIToken).(*APIToken)
+	return at
+}
+
+func (a *front) GetAPITokens(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	tokens, err := a.back.getAPITokensForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	out := make([]APITokenJSON, 0, len(tokens))
+
+	for _, at := range tokens {
+		out = append(out, apiFromAPIToken(at))
+	}
+
+	return EntityResponse{
+		Message: "ĵetonoj",
+		Entity:  out,
+	}
+}
+
+// PostAPIToken makes a new API token. The token itself is in the answer, and never again.
+// Tokens can't make more tokens, else a token could outlive itself.
+func (a *front) PostAPIToken(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	if a.apiTokenFromContext(ctx) != nil {
+		return fmt.Errorf("%w: ne per ĵetono", lib.ErrHTTPForbidden)
+	}
+
+	req, err := DecodeBody(r, &APITokenJSON{})
+	if err != nil {
+		return err
+	}
+
+	if req.Name == "" {
+		return fmt.Errorf("%w: mankas nomo", lib.ErrHTTPBadRequest)
+	}
+
+	scopes, err := checkScopes(req.Scopes)
+	if err != nil {
+		return err
+	}
+
+	if err := checkTokenExpiry(req.ExpiresAt, time.Now()); err != nil {
+		return err
+	}
+
+	at, token, err := a.back.putAPIToken(ctx, *user, req.Name, scopes, req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	a.log(ctx).Info("new api token", "user", user.ID, "token", at.ID)
+
+	out := apiFromAPIToken(at)
+	out.Token = token
+
+	return EntityResponse{
+		Message: "nova ĵetono",
+		Entity:  out,
+	}
+}
+
+// DeleteAPIToken revokes an API token. A token can revoke itself.
+func (a *front) DeleteAPIToken(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	at, err := a.back.getAPIToken(ctx, DBID(r.PathValue("token")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	if at.UserID != user.ID {
+		return lib.ErrHTTPNotFound
+	}
+
+	if err := a.back.deleteAPIToken(ctx, at); err != nil {
+		return err
+	}
+
+	a.log(ctx).Info("revoked api token", "user", user.ID, "token", at.ID)
+
+	return EntityResponse{
+		Message: "forigita ĵetono",
+		Entity:  APITokenJSON{ID: at.ID},
+	}
+}
+
+func apiFromAPIToken(in APIToken) APITokenJSON {
+	return APITokenJSON{
+		ID:         in.ID,
+		Name:       in.Name,
+		Scopes:     splitScopes(in.Scopes),
+		CreatedAt:  in.CreatedAt,
+		ExpiresAt:  in.ExpiresAt,
+		LastUsedAt: in.LastUsedAt,
+	}
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// lessonAssignment gets an assignment from the path, checking that it belongs to the lesson.
+func (a *front) lessonAssignment(ctx context.Context, r *http.Request) (Assignment, error) {
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return Assignment{}, err
+	}
+
+	assignment, err := a.back.getAssignment(ctx, DBID(r.PathValue("assignment")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Assignment{}, lib.ErrHTTPNotFound
+		}
+		return Assignment{}, err
+	}
+
+	if assignment.LessonID != lesson.ID {
+		return Assignment{}, lib.ErrHTTPNotFound
+	}
+
+	return assignment, nil
+}
+
+func (a *front) GetAssignments(ctx context.Context, r *http.Request) any {
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := a.back.getAssignmentsForLesson(ctx, lesson.ID)
+	if err != nil {
+		return err
+	}
+
+	out := make([]AssignmentJSON, 0, len(assignments))
+
+	for _, as := range assignments {
+		out = append(out, apiFromAssignment(as))
+	}
+
+	return EntityResponse{
+		Message: "taskoj de " + string(lesson.ID),
+		Entity:  out,
+	}
+}
+
+func (a *front) PostAssignment(ctx context.Context, r *http.Request) any {
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	assignment0, err := DecodeBody(r, &AssignmentJSON{})
+	if err != nil {
+		return err
+	}
+
+	if assignment0.Prompt == "" {
+		return fmt.Errorf("%w: mankas instrukcio", lib.ErrHTTPBadRequest)
+	}
+
+	if assignment0.MaxWords < 0 {
+		return fmt.Errorf("%w: malbona maks_vortoj", lib.ErrHTTPBadRequest)
+	}
+
+	assignment1, err := a.back.putAssignment(ctx, Assignment{
+		LessonID:  lesson.ID,
+		Prompt:    assignment0.Prompt,
+		DueAt:     assignment0.DueAt,
+		MaxWords:  assignment0.MaxWords,
+		AllowLate: assignment0.AllowLate,
+	})
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova tasko",
+		Entity:  apiFromAssignment(assignment1),
+	}
+}
+
+func (a *front) GetAssignment(ctx context.Context, r *http.Request) any {
+	assignment, err := a.lessonAssignment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "tasko " + string(assignment.ID),
+		Entity:  apiFromAssignment(assignment),
+	}
+}
+
+func (a *front) PatchAssignment(ctx context.Context, r *http.Request) any {
+	type assignmentPatch struct {
+		Prompt    *string    `json:"instrukcio"`
+		DueAt     *time.Time `json:"limdato"`
+		NoDue     bool       `json:"sen_limdato"`
+		MaxWords  *int       `json:"maks_vortoj"`
+		AllowLate *bool      `json:"malfruo_permesata"`
+	}
+
+	assignment0, err := a.lessonAssignment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	patch, err := DecodeBody(r, &assignmentPatch{})
+	if err != nil {
+		return err
+	}
+
+	mutates := []rel.Mutate{rel.Set("updated_at", time.Now())}
+
+	if patch.Prompt != nil {
+		if *patch.Prompt == "" {
+			return fmt.Errorf("%w: mankas instrukcio", lib.ErrHTTPBadRequest)
+		}
+		mutates = append(mutates, rel.Set("prompt", *patch.Prompt))
+	}
+
+	if patch.DueAt != nil {
+		mutates = append(mutates, rel.Set("due_at", *patch.DueAt))
+	} else if patch.NoDue {
+		mutates = append(mutates, rel.Set("due_at", nil))
+	}
+
+	if patch.MaxWords != nil {
+		if *patch.MaxWords < 0 {
+			return fmt.Errorf("%w: malbona maks_vortoj", lib.ErrHTTPBadRequest)
+		}
+		mutates = append(mutates, rel.Set("max_words", *patch.MaxWords))
+	}
+
+	if patch.AllowLate != nil {
+		mutates = append(mutates, rel.Set("allow_late", *patch.AllowLate))
+	}
+
+	if len(mutates) == 1 {
+		return fmt.Errorf("%w: nenio ŝanĝota", lib.ErrHTTPBadRequest)
+	}
+
+	assignment1, err := a.back.updateAssignment(ctx, assignment0, mutates...)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "ŝanĝita tasko",
+		Entity:  apiFromAssignment(assignment1),
+	}
+}
+
+// DeleteAssignment deletes an assignment. Homework done for it stays, but no longer refers to it.
+func (a *front) DeleteAssignment(ctx context.Context, r *http.Request) any {
+	assignment, err := a.lessonAssignment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := a.back.deleteAssignment(ctx, assignment); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita tasko",
+		Entity:  AssignmentJSON{ID: assignment.ID},
+	}
+}
+
+// GetMyAssignments lists assignments of all the user's courses that they have not done yet,
+// split into those still to come and those already overdue.
+func (a *front) GetMyAssignments(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	learners, err := a.back.getLearnersByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	courses := make([]DBID, 0, len(learners))
+	for _, l := range learners {
+		if l.CourseX.ArchivedAt == nil && l.WaitingSince == nil && l.Status == LearnerActive {
+			courses = append(courses, l.CourseID)
+		}
+	}
+
+	assignments, err := a.back.getAssignmentsForCourses(ctx, courses)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]DBID, 0, len(assignments))
+	for _, as := range assignments {
+		ids = append(ids, as.ID)
+	}
+
+	done, err := a.back.getSubmittedAssignments(ctx, user.ID, ids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	out := MyAssignmentsJSON{
+		Upcoming: []AssignmentJSON{},
+		Overdue:  []AssignmentJSON{},
+	}
+
+	for _, as := range assignments {
+		if done[as.ID] {
+			continue
+		}
+
+		if as.DueAt != nil && now.After(*as.DueAt) {
+			out.Overdue = append(out.Overdue, apiFromAssignment(as))
+		} else {
+			out.Upcoming = append(out.Upcoming, apiFromAssignment(as))
+		}
+	}
+
+	return EntityResponse{
+		Message: "miaj taskoj",
+		Entity:  out,
+	}
+}
+
+func apiFromAssignment(in Assignment) AssignmentJSON {
+	return AssignmentJSON{
+		ID: in.ID,
+		Lesson: LessonJSON{
+			ID:   in.LessonID,
+			Name: in.LessonX.Name,
+			Course: CourseJSON{
+				ID: in.LessonX.Course,
+			},
+		},
+		Prompt:    in.Prompt,
+		DueAt:     in.DueAt,
+		MaxWords:  in.MaxWords,
+		AllowLate: in.AllowLate,
+	}
+}
@@ -0,0 +1,342 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// receiveFile stores the file from the "dosiero" part of a multipart upload, filling in the
+// details of the attachment. It checks type and size before anything goes into the database.
+func (a *front) receiveFile(ctx context.Context, r *http.Request, attachment Attachment) (Attachment, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return Attachment{}, fmt.Errorf("%w: atendis multipart/form-data", lib.ErrHTTPBadRequest)
+	}
+
+	var part io.Reader
+
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return Attachment{}, fmt.Errorf("%w: mankas dosiero", lib.ErrHTTPBadRequest)
+			}
+			return Attachment{}, fmt.Errorf("%w: %v", lib.ErrHTTPBadRequest, err)
+		}
+
+		if p.FormName() == "dosiero" {
+			attachment.Name = cleanFileName(p.FileName())
+			part = p
+			break
+		}
+	}
+
+	br := bufio.NewReaderSize(part, 512)
+
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Attachment{}, fmt.Errorf("%w: %v", lib.ErrHTTPBadRequest, err)
+	}
+
+	attachment.MimeType, err = checkUploadType(head, a.files.Types)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	attachment.ID = makeRandomID("d", 6)
+	attachment.CreatedAt = time.Now()
+
+	key := attachment.BlobKey()
+
+	n, err := a.store.PutBlob(ctx, key, io.LimitReader(br, a.files.MaxSize+1))
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	if n > a.files.MaxSize {
+		a.dropBlob(ctx, key)
+		return Attachment{}, fmt.Errorf("%w: maksimume %d bajtoj", lib.ErrHTTPTooLarge, a.files.MaxSize)
+	}
+
+	attachment.Size = n
+
+	attachment, err = a.back.putAttachment(ctx, attachment)
+	if err != nil {
+		a.dropBlob(ctx, key)
+		return Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+// sendFile sends the content of an attachment, as a download.
+func (a *front) sendFile(ctx context.Context, attachment Attachment) any {
+	body, err := a.store.GetBlob(ctx, attachment.BlobKey())
+	if err != nil {
+		if errors.Is(err, lib.ErrNoBlob) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", attachment.MimeType)
+	header.Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	return lib.HTTPResponse{
+		Status: http.StatusOK,
+		Header: header,
+		Body:   body,
+	}
+}
+
+// dropBlob deletes stored content, only logging if it can't, since it is only left over.
+func (a *front) dropBlob(ctx context.Context, key string) {
+	if err := a.store.DeleteBlob(ctx, key); err != nil {
+		a.log(ctx).Warn("delete blob", "key", key, "err", err)
+	}
+}
+
+func (a *front) deleteFile(ctx context.Context, attachment Attachment) error {
+	if err := a.back.deleteAttachment(ctx, attachment); err != nil {
+		return err
+	}
+
+	a.dropBlob(ctx, attachment.BlobKey())
+
+	return nil
+}
+
+// lessonAttachment gets an attachment from the path, checking that it belongs to the lesson.
+func (a *front) lessonAttachment(ctx context.Context, r *http.Request) (Attachment, error) {
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	attachment, err := a.back.getAttachment(ctx, DBID(r.PathValue("file")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Attachment{}, lib.ErrHTTPNotFound
+		}
+		return Attachment{}, err
+	}
+
+	if attachment.LessonID == nil || *attachment.LessonID != lesson.ID {
+		return Attachment{}, lib.ErrHTTPNotFound
+	}
+
+	return attachment, nil
+}
+
+// homeworkAttachment gets an attachment from the path, checking that it belongs to the homework.
+func (a *front) homeworkAttachment(ctx context.Context, r *http.Request) (Homework, Attachment, error) {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return Homework{}, Attachment{}, err
+	}
+
+	attachment, err := a.back.getAttachment(ctx, DBID(r.PathValue("file")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Homework{}, Attachment{}, lib.ErrHTTPNotFound
+		}
+		return Homework{}, Attachment{}, err
+	}
+
+	if attachment.HomeworkID == nil || *attachment.HomeworkID != homework.ID {
+		return Homework{}, Attachment{}, lib.ErrHTTPNotFound
+	}
+
+	return homework, attachment, nil
+}
+
+// checkHomeworkEditable makes sure that the learner can still change what they hand in.
+func checkHomeworkEditable(homework Homework) error {
+	if homework.Status != HomeworkDraft && homework.Status != HomeworkReturned {
+		return fmt.Errorf("%w: hejmtasko ne ŝanĝebla nun", lib.ErrHTTPConflict)
+	}
+
+	return nil
+}
+
+func (a *front) GetLessonFiles(ctx context.Context, r *http.Request) any {
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := a.back.getAttachmentsForLesson(ctx, lesson.ID)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "dosieroj de " + string(lesson.ID),
+		Entity:  apiFromAttachments(attachments),
+	}
+}
+
+func (a *front) PostLessonFile(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	lesson, err := a.courseLesson(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := a.receiveFile(ctx, r, Attachment{
+		LessonID:   &lesson.ID,
+		UploaderID: user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	attachment.UploaderX = *user
+
+	return EntityResponse{
+		Message: "nova dosiero",
+		Entity:  apiFromAttachment(attachment),
+	}
+}
+
+func (a *front) GetLessonFile(ctx context.Context, r *http.Request) any {
+	attachment, err := a.lessonAttachment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return a.sendFile(ctx, attachment)
+}
+
+func (a *front) DeleteLessonFile(ctx context.Context, r *http.Request) any {
+	attachment, err := a.lessonAttachment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := a.deleteFile(ctx, attachment); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita dosiero",
+		Entity:  AttachmentJSON{ID: attachment.ID},
+	}
+}
+
+func (a *front) GetHomeworkFiles(ctx context.Context, r *http.Request) any {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := a.back.getAttachmentsForHomework(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "dosieroj de " + string(homework.ID),
+		Entity:  apiFromAttachments(attachments),
+	}
+}
+
+// PostHomeworkFile attaches a file to homework, while the learner can still change it.
+func (a *front) PostHomeworkFile(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	homework, err := a.writableHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := checkHomeworkEditable(homework); err != nil {
+		return err
+	}
+
+	attachment, err := a.receiveFile(ctx, r, Attachment{
+		HomeworkID: &homework.ID,
+		UploaderID: user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	attachment.UploaderX = *user
+
+	return EntityResponse{
+		Message: "nova dosiero",
+		Entity:  apiFromAttachment(attachment),
+	}
+}
+
+func (a *front) GetHomeworkFile(ctx context.Context, r *http.Request) any {
+	_, attachment, err := a.homeworkAttachment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return a.sendFile(ctx, attachment)
+}
+
+func (a *front) DeleteHomeworkFile(ctx context.Context, r *http.Request) any {
+	homework, attachment, err := a.homeworkAttachment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLearnerActive(homework.LearnerX); err != nil {
+		return err
+	}
+
+	if err := checkHomeworkEditable(homework); err != nil {
+		return err
+	}
+
+	if err := a.deleteFile(ctx, attachment); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita dosiero",
+		Entity:  AttachmentJSON{ID: attachment.ID},
+	}
+}
+
+func apiFromAttachment(in Attachment) AttachmentJSON {
+	return AttachmentJSON{
+		ID:       in.ID,
+		Name:     in.Name,
+		MimeType: in.MimeType,
+		Size:     in.Size,
+		Uploader: UserJSON{
+			ID:   in.UploaderID,
+			Name: in.UploaderX.Name,
+		},
+		Time: in.CreatedAt,
+	}
+}
+
+func apiFromAttachments(in []Attachment) []AttachmentJSON {
+	out := make([]AttachmentJSON, 0, len(in))
+
+	for _, at := range in {
+		out = append(out, apiFromAttachment(at))
+	}
+
+	return out
+}
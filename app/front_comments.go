@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// commentedHomework gets homework from the path, if the user can see the comments about it.
+// Comments are only between the learner and those who correct them, so observers, who can see
+// the homework, can't see those.
+func (a *front) commentedHomework(ctx context.Context, r *http.Request) (Homework, error) {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return Homework{}, err
+	}
+
+	if homework.LearnerX.UserID == a.userFromContext(ctx).ID {
+		return homework, nil
+	}
+
+	ok, err := a.can(ctx, homework.LessonX.Course, PermReview)
+	if err != nil {
+		return Homework{}, err
+	}
+
+	if !ok {
+		return Homework{}, lib.ErrHTTPForbidden
+	}
+
+	return homework, nil
+}
+
+// homeworkComment gets a comment from the path, checking that the user can see the comments
+// about the homework.
+func (a *front) homeworkComment(ctx context.Context, r *http.Request) (Homework, Comment, error) {
+	homework, err := a.commentedHomework(ctx, r)
+	if err != nil {
+		return Homework{}, Comment{}, err
+	}
+
+	comment, err := a.back.getComment(ctx, DBID(r.PathValue("comment")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Homework{}, Comment{}, lib.ErrHTTPNotFound
+		}
+		return Homework{}, Comment{}, err
+	}
+
+	if comment.HomeworkID != homework.ID {
+		return Homework{}, Comment{}, lib.ErrHTTPNotFound
+	}
+
+	return homework, comment, nil
+}
+
+// GetComments shows the conversation about some homework, as threads.
+func (a *front) GetComments(ctx context.Context, r *http.Request) any {
+	homework, err := a.commentedHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	comments, err := a.back.getCommentsForHomework(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	texts, err := a.back.revisionTexts(ctx, homework)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "komentoj pri " + string(homework.ID),
+		Entity:  threadComments(texts, comments),
+	}
+}
+
+func (a *front) PostComment(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	homework, err := a.writableHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	comment0, err := DecodeBody(r, &CommentJSON{})
+	if err != nil {
+		return err
+	}
+
+	if comment0.Text == "" {
+		return fmt.Errorf("%w: mankas teksto", lib.ErrHTTPBadRequest)
+	}
+
+	if comment0.ParentID != nil {
+		parent, err := a.back.getComment(ctx, *comment0.ParentID)
+		if err != nil {
+			if errors.Is(err, rel.ErrNotFound) {
+				return fmt.Errorf("%w: nekonata komento", lib.ErrHTTPBadRequest)
+			}
+			return err
+		}
+
+		if parent.HomeworkID != homework.ID {
+			return fmt.Errorf("%w: komento pri alia hejmtasko", lib.ErrHTTPBadRequest)
+		}
+	}
+
+	if err := checkCommentAnchor(homework.Text, comment0.Start, comment0.Length); err != nil {
+		return err
+	}
+
+	revision, err := a.back.latestRevision(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	comment1, err := a.back.putComment(ctx, Comment{
+		HomeworkID: homework.ID,
+		AuthorID:   user.ID,
+		ParentID:   comment0.ParentID,
+		Text:       comment0.Text,
+		Revision:   revision,
+		Start:      comment0.Start,
+		Length:     comment0.Length,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+	if err != nil {
+		return err
+	}
+
+	comment1.AuthorX = *user
+
+	return EntityResponse{
+		Message: "nova komento",
+		Entity:  apiFromComment(homework.Text, comment1),
+	}
+}
+
+// PatchComment changes the text of a comment. Only the author can do this.
+func (a *front) PatchComment(ctx context.Context, r *http.Request) any {
+	type commentPatch struct {
+		Text *string `json:"teksto"`
+	}
+
+	user := a.userFromContext(ctx)
+
+	homework, comment0, err := a.homeworkComment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if comment0.AuthorID != user.ID {
+		return lib.ErrHTTPForbidden
+	}
+
+	if err := checkLearnerActive(homework.LearnerX); err != nil {
+		return err
+	}
+
+	if comment0.RemovedAt != nil {
+		return fmt.Errorf("%w: komento forigita", lib.ErrHTTPConflict)
+	}
+
+	patch, err := DecodeBody(r, &commentPatch{})
+	if err != nil {
+		return err
+	}
+
+	if patch.Text == nil {
+		return fmt.Errorf("%w: nenio ŝanĝota", lib.ErrHTTPBadRequest)
+	}
+
+	if *patch.Text == "" {
+		return fmt.Errorf("%w: mankas teksto", lib.ErrHTTPBadRequest)
+	}
+
+	comment1, err := a.back.updateCommentText(ctx, comment0, *patch.Text)
+	if err != nil {
+		return err
+	}
+
+	texts, err := a.back.revisionTexts(ctx, homework)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "ŝanĝita komento",
+		Entity:  apiFromComment(texts[comment1.Revision], comment1),
+	}
+}
+
+// DeleteComment takes back a comment. The author can do this, and admins can too.
+func (a *front) DeleteComment(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	homework, comment, err := a.homeworkComment(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	// there is no course in the path, so only roles from everywhere count
+	admin := rolesAllow(userRoles(user), PermManageUsers)
+
+	if comment.AuthorID != user.ID && !admin {
+		return lib.ErrHTTPForbidden
+	}
+
+	if err := checkLearnerActive(homework.LearnerX); err != nil && !admin {
+		return err
+	}
+
+	if err := a.back.removeComment(ctx, comment); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita komento",
+		Entity:  CommentJSON{ID: comment.ID},
+	}
+}
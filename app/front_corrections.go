@@ -0,0 +1,235 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// reviewableHomework gets a homework from the path, if the user may correct it, while the
+// learner is still in the course.
+func (a *front) reviewableHomework(ctx context.Context, r *http.Request) (Homework, error) {
+	homework, err := a.writableHomework(ctx, r)
+	if err != nil {
+		return Homework{}, err
+	}
+
+	ok, err := a.can(ctx, homework.LessonX.Course, PermReview)
+	if err != nil {
+		return Homework{}, err
+	}
+
+	if !ok {
+		return Homework{}, lib.ErrHTTPForbidden
+	}
+
+	return homework, nil
+}
+
+// visibleCorrections gets the corrections of some homework that the user can see. Teachers
+// see everything, but the learner sees only corrections from before it was last returned.
+func (a *front) visibleCorrections(ctx context.Context, homework Homework) ([]Correction, error) {
+	user := a.userFromContext(ctx)
+
+	corrections, err := a.back.getCorrectionsForHomework(ctx, homework.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if homework.LearnerX.UserID != user.ID {
+		return corrections, nil
+	}
+
+	out := make([]Correction, 0, len(corrections))
+
+	for _, c := range corrections {
+		if homework.ReturnedAt != nil && !c.CreatedAt.After(*homework.ReturnedAt) {
+			out = append(out, c)
+		}
+	}
+
+	return out, nil
+}
+
+func (a *front) GetCorrections(ctx context.Context, r *http.Request) any {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	corrections, err := a.visibleCorrections(ctx, homework)
+	if err != nil {
+		return err
+	}
+
+	texts, err := a.back.revisionTexts(ctx, homework)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "korektoj de " + string(homework.ID),
+		Entity:  apiFromCorrections(texts, corrections),
+	}
+}
+
+func (a *front) PostCorrection(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	homework, err := a.reviewableHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	correction0, err := DecodeBody(r, &CorrectionJSON{})
+	if err != nil {
+		return err
+	}
+
+	if correction0.Text == "" && len(correction0.Annotations) == 0 {
+		return fmt.Errorf("%w: mankas korekto", lib.ErrHTTPBadRequest)
+	}
+
+	annotations := make([]Annotation, 0, len(correction0.Annotations))
+
+	for _, an := range correction0.Annotations {
+		annotations = append(annotations, Annotation{
+			Start:       an.Start,
+			Length:      an.Length,
+			Replacement: an.Replacement,
+			Comment:     an.Comment,
+			Category:    an.Category,
+		})
+	}
+
+	if err := checkAnnotations(homework.Text, annotations); err != nil {
+		return err
+	}
+
+	switch homework.Status {
+	case HomeworkInReview:
+		// only whoever claimed it adds to it, unless the owner takes over
+		override, err := a.can(ctx, homework.LessonX.Course, PermManageStaff)
+		if err != nil {
+			return err
+		}
+
+		if err := checkReviewer(homework, user.ID, override); err != nil {
+			return err
+		}
+	case HomeworkSubmitted:
+		// correcting claims the homework
+		homework, err = a.back.setHomeworkStatus(ctx, homework, HomeworkInReview, user, false)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: hejmtasko ne atendas korektadon", lib.ErrHTTPConflict)
+	}
+
+	revision, err := a.back.latestRevision(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	correction1, err := a.back.putCorrection(ctx, Correction{
+		HomeworkID:  homework.ID,
+		TeacherID:   user.ID,
+		TeacherX:    *user,
+		Text:        correction0.Text,
+		Revision:    revision,
+		Annotations: annotations,
+	})
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova korekto",
+		Entity:  apiFromCorrection(homework.Text, correction1),
+	}
+}
+
+// DeleteCorrection takes back a correction, which only its author or an admin can do.
+func (a *front) DeleteCorrection(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	homework, err := a.reviewableHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	correction, err := a.back.getCorrection(ctx, DBID(r.PathValue("correction")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	if correction.HomeworkID != homework.ID {
+		return lib.ErrHTTPNotFound
+	}
+
+	if correction.TeacherID != user.ID && !rolesAllow(userRoles(user), PermManageUsers) {
+		return lib.ErrHTTPForbidden
+	}
+
+	if err := a.back.deleteCorrection(ctx, correction); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita korekto",
+		Entity:  CorrectionJSON{ID: correction.ID},
+	}
+}
+
+// apiFromCorrection shows a correction, cutting up the text that it was made on.
+func apiFromCorrection(text string, in Correction) CorrectionJSON {
+	annotations := make([]AnnotationJSON, 0, len(in.Annotations))
+
+	for _, an := range in.Annotations {
+		annotations = append(annotations, AnnotationJSON{
+			ID:          an.ID,
+			Start:       an.Start,
+			Length:      an.Length,
+			Replacement: an.Replacement,
+			Comment:     an.Comment,
+			Category:    an.Category,
+		})
+	}
+
+	return CorrectionJSON{
+		ID: in.ID,
+		Teacher: UserJSON{
+			ID:   in.TeacherID,
+			Name: in.TeacherX.Name,
+		},
+		Text:        in.Text,
+		Revision:    in.Revision,
+		Annotations: annotations,
+		Time:        in.CreatedAt,
+		Segments:    segmentText(text, annotations),
+	}
+}
+
+// apiFromCorrections shows corrections, given the texts of all revisions.
+func apiFromCorrections(texts map[int]string, in []Correction) []CorrectionJSON {
+	out := make([]CorrectionJSON, 0, len(in))
+
+	for _, c := range in {
+		text, ok := texts[c.Revision]
+		if !ok {
+			text = texts[0]
+		}
+
+		out = append(out, apiFromCorrection(text, c))
+	}
+
+	return out
+}
@@ -0,0 +1,332 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// courseRubric gets a rubric from the path, checking that it belongs to the course.
+func (a *front) courseRubric(ctx context.Context, r *http.Request) (Rubric, error) {
+	courseID, rubricID := r.PathValue("course"), r.PathValue("rubric")
+	if courseID == "" || rubricID == "" {
+		return Rubric{}, lib.ErrHTTPNotFound
+	}
+
+	rubric, err := a.back.getRubric(ctx, DBID(rubricID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Rubric{}, lib.ErrHTTPNotFound
+		}
+		return Rubric{}, err
+	}
+
+	if rubric.CourseID != DBID(courseID) {
+		return Rubric{}, lib.ErrHTTPNotFound
+	}
+
+	return rubric, nil
+}
+
+func (a *front) GetRubrics(ctx context.Context, r *http.Request) any {
+	courseID := r.PathValue("course")
+	if courseID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	rubrics, err := a.back.getRubricsForCourse(ctx, DBID(courseID))
+	if err != nil {
+		return err
+	}
+
+	out := make([]RubricJSON, 0, len(rubrics))
+
+	for _, ru := range rubrics {
+		out = append(out, apiFromRubric(ru))
+	}
+
+	return EntityResponse{
+		Message: "rubrikoj de " + courseID,
+		Entity:  out,
+	}
+}
+
+func (a *front) PostRubric(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	rubric0, err := DecodeBody(r, &RubricJSON{})
+	if err != nil {
+		return err
+	}
+
+	if rubric0.Name == "" {
+		return fmt.Errorf("%w: mankas nomo", lib.ErrHTTPBadRequest)
+	}
+
+	if len(rubric0.Criteria) == 0 {
+		return fmt.Errorf("%w: mankas kriterioj", lib.ErrHTTPBadRequest)
+	}
+
+	criteria := make([]RubricCriterion, 0, len(rubric0.Criteria))
+
+	for i, c := range rubric0.Criteria {
+		if c.Name == "" {
+			return fmt.Errorf("%w: kriterio %d: mankas nomo", lib.ErrHTTPBadRequest, i)
+		}
+
+		if c.MaxPoints <= 0 {
+			return fmt.Errorf("%w: kriterio %d: malbona maks_poentoj", lib.ErrHTTPBadRequest, i)
+		}
+
+		criteria = append(criteria, RubricCriterion{
+			Name:      c.Name,
+			MaxPoints: c.MaxPoints,
+		})
+	}
+
+	now := time.Now()
+
+	rubric1, err := a.back.putRubric(ctx, Rubric{
+		CourseID:  course.ID,
+		Name:      rubric0.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Criteria:  criteria,
+	})
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova rubriko",
+		Entity:  apiFromRubric(rubric1),
+	}
+}
+
+func (a *front) GetRubric(ctx context.Context, r *http.Request) any {
+	rubric, err := a.courseRubric(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "rubriko " + string(rubric.ID),
+		Entity:  apiFromRubric(rubric),
+	}
+}
+
+// DeleteRubric deletes a rubric, and all grades that were given with it.
+func (a *front) DeleteRubric(ctx context.Context, r *http.Request) any {
+	rubric, err := a.courseRubric(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := a.back.deleteRubric(ctx, rubric); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita rubriko",
+		Entity:  RubricJSON{ID: rubric.ID},
+	}
+}
+
+// GetGrades shows the grades of some homework. The learner sees them only once the homework
+// has been returned, and not any given since, as after a resubmission.
+func (a *front) GetGrades(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	grades, err := a.back.getGradesForHomework(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	if homework.LearnerX.UserID == user.ID {
+		grades = returnedGrades(homework, grades)
+	}
+
+	return EntityResponse{
+		Message: "notoj de " + string(homework.ID),
+		Entity:  apiFromGrades(homework.ID, grades),
+	}
+}
+
+// PutGrades grades some homework using one of the rubrics of its course, replacing any
+// grades it had before. Only homework being reviewed can be graded, by whoever claimed it.
+func (a *front) PutGrades(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	homework, err := a.reviewableHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if homework.Status != HomeworkInReview && homework.Status != HomeworkCorrected {
+		return fmt.Errorf("%w: hejmtasko ne estas korektata", lib.ErrHTTPConflict)
+	}
+
+	override, err := a.can(ctx, homework.LessonX.Course, PermManageStaff)
+	if err != nil {
+		return err
+	}
+
+	if err := checkReviewer(homework, user.ID, override); err != nil {
+		return err
+	}
+
+	grades0, err := DecodeBody(r, &HomeworkGradesJSON{})
+	if err != nil {
+		return err
+	}
+
+	if grades0.Rubric == "" {
+		return fmt.Errorf("%w: mankas rubriko", lib.ErrHTTPBadRequest)
+	}
+
+	rubric, err := a.back.getRubric(ctx, grades0.Rubric)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("%w: nekonata rubriko", lib.ErrHTTPBadRequest)
+		}
+		return err
+	}
+
+	if rubric.CourseID != homework.LessonX.Course {
+		return fmt.Errorf("%w: rubriko de alia kurso", lib.ErrHTTPBadRequest)
+	}
+
+	grades := make([]Grade, 0, len(grades0.Grades))
+
+	for _, g := range grades0.Grades {
+		grades = append(grades, Grade{
+			CriterionID: g.Criterion.ID,
+			Points:      g.Points,
+			Comment:     g.Comment,
+		})
+	}
+
+	if err := checkGrades(rubric, grades); err != nil {
+		return err
+	}
+
+	if _, err := a.back.putGrades(ctx, homework.ID, user.ID, grades); err != nil {
+		return err
+	}
+
+	grades, err = a.back.getGradesForHomework(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "notoj de " + string(homework.ID),
+		Entity:  apiFromGrades(homework.ID, grades),
+	}
+}
+
+// GetGradebook shows the scores of all learners of a course for all its assignments, as JSON
+// or, with ?formato=csv, as a spreadsheet.
+func (a *front) GetGradebook(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	format := r.URL.Query().Get("formato")
+	if format != "" && format != "json" && format != "csv" {
+		return fmt.Errorf("%w: nekonata formato %q", lib.ErrHTTPBadRequest, format)
+	}
+
+	assignments, err := a.back.getAssignmentsForCourses(ctx, []DBID{course.ID})
+	if err != nil {
+		return err
+	}
+
+	learners, err := a.back.getLearnersByCourse(ctx, course.ID)
+	if err != nil {
+		return err
+	}
+
+	homeworks, grades, err := a.back.getGradedHomeworksForCourse(ctx, course.ID)
+	if err != nil {
+		return err
+	}
+
+	gradebook := buildGradebook(assignments, learners, homeworks, grades)
+
+	if format == "csv" {
+		buf := &bytes.Buffer{}
+		if err := writeGradebookCSV(buf, gradebook); err != nil {
+			return err
+		}
+
+		header := http.Header{}
+		header.Set("Content-Type", "text/csv; charset=utf-8")
+		header.Set("Content-Disposition", `attachment; filename="notoj-`+string(course.ID)+`.csv"`)
+
+		return lib.HTTPResponse{
+			Status: http.StatusOK,
+			Header: header,
+			Body:   buf,
+		}
+	}
+
+	return EntityResponse{
+		Message: "notoj de " + string(course.ID),
+		Entity:  gradebook,
+	}
+}
+
+func apiFromRubric(in Rubric) RubricJSON {
+	criteria := make([]CriterionJSON, 0, len(in.Criteria))
+
+	for _, c := range in.Criteria {
+		criteria = append(criteria, CriterionJSON{
+			ID:        c.ID,
+			Name:      c.Name,
+			MaxPoints: c.MaxPoints,
+		})
+	}
+
+	return RubricJSON{
+		ID: in.ID,
+		Course: CourseJSON{
+			ID: in.CourseID,
+		},
+		Name:     in.Name,
+		Criteria: criteria,
+	}
+}
+
+func apiFromGrades(homework DBID, in []Grade) HomeworkGradesJSON {
+	out := HomeworkGradesJSON{
+		Homework: homework,
+		Grades:   make([]GradeJSON, 0, len(in)),
+	}
+
+	for _, g := range in {
+		out.Rubric = g.CriterionX.RubricID
+		out.Grades = append(out.Grades, GradeJSON{
+			Criterion: CriterionJSON{
+				ID:        g.CriterionID,
+				Name:      g.CriterionX.Name,
+				MaxPoints: g.CriterionX.MaxPoints,
+			},
+			Points:  g.Points,
+			Comment: g.Comment,
+		})
+	}
+
+	out.Total, out.MaxPoints = sumGrades(in)
+
+	return out
+}
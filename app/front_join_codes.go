@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+const joinCodeLength = 8
+
+// GetJoinCode shows the join code of a course, so that teachers can hand it out.
+func (a *front) GetJoinCode(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	if course.JoinCode == "" {
+		return fmt.Errorf("%w: neniu kodo", lib.ErrHTTPNotFound)
+	}
+
+	return EntityResponse{
+		Message: "aliĝkodo de " + string(course.ID),
+		Entity:  apiFromJoinCode(course),
+	}
+}
+
+// PostJoinCode makes a new join code for a course. Any old code stops working.
+func (a *front) PostJoinCode(ctx context.Context, r *http.Request) any {
+	course0 := a.courseFromContext(ctx)
+
+	req, err := DecodeBody(r, &JoinCodeJSON{})
+	if err != nil {
+		return err
+	}
+
+	if req.MaxUses < 0 {
+		return fmt.Errorf("%w: malbona maks_uzoj", lib.ErrHTTPBadRequest)
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: limdato jam pasis", lib.ErrHTTPBadRequest)
+	}
+
+	code, err := lib.MakeSecretCode(joinCodeLength)
+	if err != nil {
+		return err
+	}
+
+	course1, err := a.back.updateCourse(ctx, course0,
+		rel.Set("join_code", code),
+		rel.Set("join_expires_at", req.ExpiresAt),
+		rel.Set("join_max_uses", req.MaxUses),
+		rel.Set("join_uses", 0))
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova aliĝkodo",
+		Entity:  apiFromJoinCode(course1),
+	}
+}
+
+// DeleteJoinCode stops users joining a course themselves.
+func (a *front) DeleteJoinCode(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	_, err := a.back.updateCourse(ctx, course,
+		rel.Set("join_code", ""),
+		rel.Set("join_expires_at", nil),
+		rel.Set("join_max_uses", 0),
+		rel.Set("join_uses", 0))
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita aliĝkodo",
+		Entity:  JoinCodeJSON{},
+	}
+}
+
+// PostJoin lets any user become a learner of a course, if they know its join code.
+func (a *front) PostJoin(ctx context.Context, r *http.Request) any {
+	user := a.userFromContext(ctx)
+
+	courseID := r.PathValue("course")
+	if courseID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	req, err := DecodeBody(r, &JoinCodeJSON{})
+	if err != nil {
+		return err
+	}
+
+	course, err := a.back.getCourse(ctx, DBID(courseID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	if course.ArchivedAt != nil {
+		return fmt.Errorf("%w: kurso arkivita", lib.ErrHTTPConflict)
+	}
+
+	if err := checkJoinCode(course, req.Code, time.Now()); err != nil {
+		return err
+	}
+
+	learner, err := a.back.joinCourse(ctx, user.ID, course, strings.ToUpper(strings.TrimSpace(req.Code)))
+	if err != nil {
+		return err
+	}
+
+	return a.newLearnerResponse(ctx, learner, course)
+}
+
+func apiFromJoinCode(in Course) JoinCodeJSON {
+	return JoinCodeJSON{
+		Code:      in.JoinCode,
+		ExpiresAt: in.JoinExpiresAt,
+		MaxUses:   in.JoinMaxUses,
+		Uses:      in.JoinUses,
+	}
+}
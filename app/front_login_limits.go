@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// checkLogin checks an email and password, as getUserByLogin does, but slows down whoever
+// gets them wrong, and locks accounts that have had too many guesses. Every failure is
+// logged as a security event. A locked account looks just like a wrong password, so that
+// nobody learns from it that the account exists; only the log says why.
+func (a *front) checkLogin(ctx context.Context, r *http.Request, email, password string) (User, error) {
+	now := time.Now()
+	ip := remoteIP(r)
+
+	if wait := a.limits.wait(ip, email, now); wait > 0 {
+		a.log(ctx).Warn("security", "event", "login_too_soon", "ip", ip, "email", email, "wait", wait)
+		return User{}, errTooManyTries{wait: wait}
+	}
+
+	user, err := a.back.getUserByLogin(ctx, email, password)
+	if err == nil {
+		// with TOTP, logging in is not done yet, so the code can't be guessed between passwords
+		if user.TOTPEnabledAt == nil {
+			a.limits.succeed(email)
+		}
+		return user, nil
+	}
+
+	switch {
+	case errors.Is(err, rel.ErrNotFound):
+		wait := a.limits.fail(ip, email, now)
+
+		locked, err1 := a.back.failLogin(ctx, email, a.limits.cfg.LockoutFailures, a.limits.cfg.LockoutTime)
+		if err1 != nil {
+			return User{}, err1
+		}
+
+		a.log(ctx).Warn("security", "event", "login_failed", "ip", ip, "email", email, "wait", wait)
+
+		if locked {
+			a.log(ctx).Warn("security", "event", "account_locked", "ip", ip, "email", email)
+		}
+	case errors.Is(err, ErrLocked):
+		// still guessing, so the address is slowed down too
+		wait := a.limits.fail(ip, email, now)
+
+		a.log(ctx).Warn("security", "event", "login_locked", "ip", ip, "email", email, "wait", wait)
+
+		return User{}, rel.ErrNotFound
+	}
+
+	return User{}, err
+}
+
+// checkLoginCode checks the second factor at login, as checkSecondFactor does, but with the
+// same limits as passwords. Once the account has to wait, the pending session is no good any
+// more, and the password has to be given again.
+func (a *front) checkLoginCode(ctx context.Context, r *http.Request, user User, code string) error {
+	now := time.Now()
+	ip := remoteIP(r)
+
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		a.log(ctx).Warn("security", "event", "login_code_locked", "ip", ip, "user", user.ID)
+		return fmt.Errorf("%w: %w", lib.ErrHTTPForbidden, ErrLocked)
+	}
+
+	if wait := a.limits.wait(ip, user.Email, now); wait > 0 {
+		a.log(ctx).Warn("security", "event", "login_code_too_soon", "ip", ip, "user", user.ID, "wait", wait)
+		return errTooManyTries{wait: wait}
+	}
+
+	err := a.checkSecondFactor(ctx, user, code)
+	if err == nil {
+		a.limits.succeed(user.Email)
+
+		if user.FailedLogins > 0 || user.LockedUntil != nil {
+			if _, err := a.back.unlockUser(ctx, user); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if !errors.Is(err, lib.ErrHTTPForbidden) {
+		return err
+	}
+
+	wait := a.limits.fail(ip, user.Email, now)
+
+	locked, err1 := a.back.failLogin(ctx, user.Email, a.limits.cfg.LockoutFailures, a.limits.cfg.LockoutTime)
+	if err1 != nil {
+		return err1
+	}
+
+	a.log(ctx).Warn("security", "event", "login_code_failed", "ip", ip, "user", user.ID, "wait", wait)
+
+	if locked {
+		a.log(ctx).Warn("security", "event", "account_locked", "ip", ip, "user", user.ID)
+	}
+
+	if wait > 0 || locked {
+		if cookie, err1 := r.Cookie(a.cookie.Name); err1 == nil {
+			if err1 := a.ident.deleteSession(ctx, cookie.Value); err1 != nil {
+				return err1
+			}
+		}
+	}
+
+	return err
+}
+
+// sendLoginError is SendHTTPError, but tells clients how long to wait, if they must.
+func sendLoginError(w http.ResponseWriter, err error) {
+	var tm errTooManyTries
+	if errors.As(err, &tm) {
+		w.Header().Set("Retry-After", tm.retryAfter())
+	}
+
+	lib.SendHTTPError(w, 0, err)
+}
+
+// tooManyTriesResponse is for handlers, which can't set headers with an error.
+func tooManyTriesResponse(tm errTooManyTries) lib.HTTPResponse {
+	return lib.HTTPResponse{
+		Status: http.StatusTooManyRequests,
+		Header: http.Header{"Retry-After": {tm.retryAfter()}},
+		Data:   map[string]string{"error": tm.Error()},
+	}
+}
+
+// PostUnlockUser lets an admin unlock an account that has been locked for wrong passwords.
+func (a *front) PostUnlockUser(ctx context.Context, r *http.Request) any {
+	me := a.userFromContext(ctx)
+
+	user0, err := a.back.getUser(ctx, DBID(r.PathValue("user")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	user1, err := a.back.unlockUser(ctx, user0)
+	if err != nil {
+		return err
+	}
+
+	a.limits.succeed(user1.Email)
+
+	a.log(ctx).Warn("security", "event", "account_unlocked", "user", user1.ID, "admin", me.ID)
+
+	return EntityResponse{
+		Message: "konto malŝlosita",
+		Entity:  apiFromUser(user1),
+	}
+}
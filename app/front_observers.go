@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func (a *front) GetObservers(ctx context.Context, r *http.Request) any {
+	courseID := r.PathValue("course")
+	if courseID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	observers, err := a.back.getObserversByCourse(ctx, DBID(courseID))
+	if err != nil {
+		return err
+	}
+
+	out := make([]ObserverJSON, 0, len(observers))
+
+	for _, o := range observers {
+		out = append(out, ObserverJSON{
+			ID: o.ID,
+			User: UserJSON{
+				ID:   o.UserID,
+				Name: o.UserX.Name,
+			},
+		})
+	}
+
+	return EntityResponse{
+		Message: "observantoj de " + courseID,
+		Entity:  out,
+	}
+}
+
+func (a *front) PostObservers(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	observer0, err := DecodeBody(r, &ObserverJSON{})
+	if err != nil {
+		return err
+	}
+
+	if observer0.Course.ID != "" && observer0.Course.ID != course.ID {
+		return lib.ErrHTTPBadRequest
+	}
+
+	user, err := a.back.getUser(ctx, observer0.User.ID)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPBadRequest
+		}
+		return err
+	}
+
+	observer1, err := a.back.addObserverToCourse(ctx, user.ID, course.ID)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova observanto",
+		Entity: ObserverJSON{
+			ID: observer1.ID,
+			User: UserJSON{
+				ID:   user.ID,
+				Name: user.Name,
+			},
+			Course: CourseJSON{
+				ID: course.ID,
+			},
+		},
+	}
+}
+
+func (a *front) DeleteObserver(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	observer, err := a.back.getObserver(ctx, DBID(r.PathValue("observer")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	if observer.CourseID != course.ID {
+		return lib.ErrHTTPNotFound
+	}
+
+	if err := a.back.removeObserver(ctx, observer); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita observanto",
+		Entity:  ObserverJSON{ID: observer.ID},
+	}
+}
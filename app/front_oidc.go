@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// oidcCookie carries the state of a login at the provider, so that only the browser that
+// started a login can finish it. It has to come back on a redirect from another site, so it
+// can't be strict.
+func (a *front) oidcCookie(value string, expires time.Time) *http.Cookie {
+	cookie := a.sessionCookie(value, expires)
+	cookie.Name = a.cookie.Name + "-OIDC"
+
+	if cookie.SameSite == http.SameSiteStrictMode {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+
+	return cookie
+}
+
+// GetOIDCLogin sends the browser to log in at the provider, if there is one.
+func (a *front) GetOIDCLogin(ctx context.Context, r *http.Request) any {
+	if a.oidc == nil {
+		return lib.ErrHTTPNotFound
+	}
+
+	state, err := lib.MakeSecretToken("stato", 32)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := lib.MakeSecretToken("nonco", 32)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := lib.MakePKCEVerifier()
+	if err != nil {
+		return err
+	}
+
+	authURL, err := a.oidc.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return err
+	}
+
+	if err := a.back.putOIDCLogin(ctx, state, nonce, verifier, oidcLoginTTL); err != nil {
+		return err
+	}
+
+	return lib.HTTPResponse{
+		Status:  http.StatusSeeOther,
+		Header:  http.Header{"Location": {authURL}},
+		Cookies: []*http.Cookie{a.oidcCookie(state, time.Now().Add(oidcLoginTTL))},
+	}
+}
+
+// GetOIDCCallback is where the provider sends the browser back to. The user is logged in as
+// whoever the ID token says, and sent on to the site. Users with TOTP still have to give a
+// code, as after a password, so the site is told to ask for one.
+func (a *front) GetOIDCCallback(ctx context.Context, r *http.Request) any {
+	if a.oidc == nil {
+		return lib.ErrHTTPNotFound
+	}
+
+	q := r.URL.Query()
+
+	if e := q.Get("error"); e != "" {
+		a.log(ctx).Info("oidc refused", "error", e, "description", q.Get("error_description"))
+		return fmt.Errorf("%w: provizanto rifuzis", lib.ErrHTTPForbidden)
+	}
+
+	state := q.Get("state")
+
+	cookie, err := r.Cookie(a.oidcCookie("", time.Time{}).Name)
+	if err != nil || state == "" || cookie.Value != state {
+		return fmt.Errorf("%w: nekonata ensaluto", lib.ErrHTTPForbidden)
+	}
+
+	login, err := a.back.useOIDCLogin(ctx, state)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("%w: nekonata ensaluto", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	raw, err := a.oidc.Exchange(ctx, q.Get("code"), login.Verifier)
+	if err != nil {
+		if errors.Is(err, lib.ErrOIDC) {
+			a.log(ctx).Warn("oidc exchange", "err", err)
+			return fmt.Errorf("%w: provizanto rifuzis", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	claims, err := a.oidc.VerifyIDToken(ctx, raw, login.Nonce, time.Now())
+	if err != nil {
+		if errors.Is(err, lib.ErrOIDC) {
+			a.log(ctx).Warn("oidc id token", "err", err)
+			return fmt.Errorf("%w: malbona ĵetono de provizanto", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	user, claimed, err := a.back.getOIDCUser(ctx, claims, a.oidcConfig.Provision)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			a.log(ctx).Info("oidc unknown user", "sub", claims.Subject)
+			return fmt.Errorf("%w: neniu konto", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	if claimed {
+		if err := a.ident.deleteUserSessions(ctx, user.ID); err != nil {
+			return err
+		}
+
+		a.log(ctx).Warn("security", "event", "account_claimed", "user", user.ID, "sub", claims.Subject)
+	}
+
+	// never carry on with a session from before login
+	if cookie, err := r.Cookie(a.cookie.Name); err == nil {
+		if err := a.ident.deleteSession(ctx, cookie.Value); err != nil {
+			return err
+		}
+	}
+
+	next := a.publicURL
+	newSession := a.ident.putSession
+
+	if user.TOTPEnabledAt != nil {
+		next = strings.TrimRight(a.publicURL, "/") + "/?" + url.Values{"dufaktora": {"kodo"}}.Encode()
+		newSession = a.ident.putPendingSession
+	}
+
+	session, token, err := newSession(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	a.log(ctx).Info("oidc login", "user", user.ID, "sub", claims.Subject, "pending", session.Pending)
+
+	return lib.HTTPResponse{
+		Status: http.StatusSeeOther,
+		Header: http.Header{"Location": {next}},
+		Cookies: []*http.Cookie{
+			a.sessionCookie(token, session.ExpiresAt),
+			a.oidcCookie("", time.Time{}),
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// PostForgotPassword emails a password reset token to a user. The answer is always the same,
+// and comes before anything is looked up, so that neither it nor its timing says whether the
+// email is known.
+func (a *front) PostForgotPassword(ctx context.Context, r *http.Request) any {
+	type forgotReq struct {
+		Email string `json:"retpoŝto"`
+	}
+
+	req, err := DecodeBody(r, &forgotReq{})
+	if err != nil {
+		return err
+	}
+
+	if req.Email == "" {
+		return fmt.Errorf("%w: mankas retpoŝto", lib.ErrHTTPBadRequest)
+	}
+
+	// every request counts, so that no one's inbox can be flooded
+	now := time.Now()
+	ip := remoteIP(r)
+
+	if wait := a.resetLimits.wait(ip, req.Email, now); wait > 0 {
+		a.log(ctx).Warn("security", "event", "reset_too_soon", "ip", ip, "email", req.Email, "wait", wait)
+		return tooManyTriesResponse(errTooManyTries{wait: wait})
+	}
+
+	a.resetLimits.fail(ip, req.Email, now)
+
+	go a.sendResetToken(context.WithoutCancel(ctx), req.Email)
+
+	return EntityResponse{
+		Message: "se la retpoŝto estas konata, mesaĝo estas sendita",
+	}
+}
+
+// sendResetToken does the work of PostForgotPassword, after the answer has gone, so it can
+// only log what happens.
+func (a *front) sendResetToken(ctx context.Context, email string) {
+	user, err := a.back.getUserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, rel.ErrNotFound) {
+			a.log(ctx).Error("reset password", "err", err)
+		}
+		return
+	}
+
+	token, err := a.back.putUserToken(ctx, user, TokenReset, a.resetTTL)
+	if err != nil {
+		a.log(ctx).Error("reset password", "user", user.ID, "err", err)
+		return
+	}
+
+	subject, body := resetMail(a.publicURL, user.Name, token)
+
+	if err := a.sendMail(ctx, user.Email, subject, body); err != nil {
+		a.log(ctx).Error("reset password", "user", user.ID, "err", err)
+		return
+	}
+
+	a.log(ctx).Info("sent reset token", "user", user.ID)
+}
+
+// PostResetPassword takes back a token from a reset email, with a new password. Every session
+// and API token of the user ends, and any other reset tokens stop working. Since the token
+// came by email, the email is confirmed too, and the account is unlocked.
+func (a *front) PostResetPassword(ctx context.Context, r *http.Request) any {
+	type resetReq struct {
+		Token    string `json:"ĵetono"`
+		Password string `json:"pasvorto"`
+	}
+
+	req, err := DecodeBody(r, &resetReq{})
+	if err != nil {
+		return err
+	}
+
+	if req.Token == "" {
+		return fmt.Errorf("%w: mankas ĵetono", lib.ErrHTTPBadRequest)
+	}
+
+	if err := checkNewPassword(req.Password); err != nil {
+		return err
+	}
+
+	user0, err := a.back.useUserToken(ctx, req.Token, TokenReset)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("%w: nevalida ĵetono", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	user1, err := a.back.setUserPassword(ctx, user0, req.Password)
+	if err != nil {
+		return err
+	}
+
+	if err := a.back.deleteUserTokens(ctx, user1.ID, TokenReset); err != nil {
+		return err
+	}
+
+	user2, err := a.back.verifyUser(ctx, user1)
+	if err != nil {
+		return err
+	}
+
+	if user2.FailedLogins > 0 || user2.LockedUntil != nil {
+		user2, err = a.back.unlockUser(ctx, user2)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.limits.succeed(user2.Email)
+
+	if err := a.ident.deleteUserSessions(ctx, user2.ID); err != nil {
+		return err
+	}
+
+	if err := a.back.deleteUserAPITokens(ctx, user2.ID); err != nil {
+		return err
+	}
+
+	a.log(ctx).Info("reset password", "user", user2.ID)
+
+	return EntityResponse{
+		Message: "pasvorto ŝanĝita",
+	}
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// access is what the policy found out about a request, for the handler to use.
+type access struct {
+	course *Course
+	roles  []Role
+}
+
+// findAccess works out the roles of the user for a request. If the path has a course, then
+// roles in that course count too.
+func (a *front) findAccess(ctx context.Context, r *http.Request) (access, error) {
+	user := a.userFromContext(ctx)
+
+	courseID := r.PathValue("course")
+	if courseID == "" {
+		return access{roles: userRoles(user)}, nil
+	}
+
+	course, err := a.back.getCourse(ctx, DBID(courseID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return access{}, lib.ErrHTTPNotFound
+		}
+		return access{}, err
+	}
+
+	roles, err := a.back.getCourseRoles(ctx, user, course)
+	if err != nil {
+		return access{}, err
+	}
+
+	return access{course: &course, roles: roles}, nil
+}
+
+// policy is middleware that finds the roles of the user, and lets the request through if
+// allow says yes.
+func (a *front) policy(allow func(r *http.Request, user *User, roles []Role) bool) lib.MiddlewareFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			acc, err := a.findAccess(ctx, r)
+			if err != nil {
+				lib.SendHTTPError(w, 0, err)
+				return
+			}
+
+			if !allow(r, a.userFromContext(ctx), acc.roles) {
+				lib.SendHTTPError(w, 0, lib.ErrHTTPForbidden)
+				return
+			}
+
+			next(w, r.WithContext(context.WithValue(ctx, ctxKeyAccess, &acc)))
+		}
+	}
+}
+
+// need lets a request through only if the user has a permission.
+func (a *front) need(perm Permission) lib.MiddlewareFunc {
+	return a.policy(func(_ *http.Request, _ *User, roles []Role) bool {
+		return rolesAllow(roles, perm)
+	})
+}
+
+// needOrSelf is like need, but also lets users through to paths about themselves.
+func (a *front) needOrSelf(perm Permission) lib.MiddlewareFunc {
+	return a.policy(func(r *http.Request, user *User, roles []Role) bool {
+		return user.ID == DBID(r.PathValue("user")) || rolesAllow(roles, perm)
+	})
+}
+
+// inCourse lets anyone through, for handlers that decide for themselves, once they know more.
+func (a *front) inCourse() lib.MiddlewareFunc {
+	return a.policy(func(*http.Request, *User, []Role) bool {
+		return true
+	})
+}
+
+func (a *front) accessFromContext(ctx context.Context) *access {
+	return ctx.Value(ctxKeyAccess).(*access)
+}
+
+// courseFromContext gets the course that the policy found for the request.
+func (a *front) courseFromContext(ctx context.Context) Course {
+	return *a.accessFromContext(ctx).course
+}
+
+// allowed says whether the user has a permission for the request, as the policy found it.
+func (a *front) allowed(ctx context.Context, perm Permission) bool {
+	return rolesAllow(a.accessFromContext(ctx).roles, perm)
+}
+
+// can says whether the user has a permission in some course, for when the course is only
+// known from something else, such as homework.
+func (a *front) can(ctx context.Context, courseID DBID, perm Permission) (bool, error) {
+	user := a.userFromContext(ctx)
+
+	if rolesAllow(userRoles(user), perm) {
+		return true, nil
+	}
+
+	course, err := a.back.getCourse(ctx, courseID)
+	if err != nil {
+		return false, err
+	}
+
+	roles, err := a.back.getCourseRoles(ctx, user, course)
+	if err != nil {
+		return false, err
+	}
+
+	return rolesAllow(roles, perm), nil
+}
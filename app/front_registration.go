@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+const mailTimeout = 30 * time.Second
+
+func (a *front) sendMail(ctx context.Context, to, subject, body string) error {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+
+	if err := a.mail.SendMail(ctx, to, subject, body); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+// PostRegister lets someone make their own account, if registration is open. They cannot log
+// in until they send back the token that is emailed to them. The answer is the same whether
+// or not the email was already known, so that this can't be used to find out.
+func (a *front) PostRegister(ctx context.Context, r *http.Request) any {
+	if !a.registration.Open {
+		return lib.ErrHTTPNotFound
+	}
+
+	req, err := DecodeBody(r, &UserJSON{})
+	if err != nil {
+		return err
+	}
+
+	if err := checkRegistration(req.Name, req.Email, req.Password); err != nil {
+		return err
+	}
+
+	// every request sends an email, so every request counts, as for password resets
+	now := time.Now()
+	ip := remoteIP(r)
+
+	if wait := a.registerLimits.wait(ip, req.Email, now); wait > 0 {
+		a.log(ctx).Warn("security", "event", "register_too_soon", "ip", ip, "email", req.Email, "wait", wait)
+		return tooManyTriesResponse(errTooManyTries{wait: wait})
+	}
+
+	a.registerLimits.fail(ip, req.Email, now)
+
+	accepted := EntityResponse{
+		Message: "kontrolu vian retpoŝton",
+	}
+
+	user, err := a.back.putUser(ctx, User{
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		if !errors.Is(err, lib.ErrHTTPConflict) {
+			return err
+		}
+
+		user, err = a.back.getUserByEmail(ctx, req.Email)
+		if err != nil {
+			if errors.Is(err, rel.ErrNotFound) {
+				// the account was deleted
+				return accepted
+			}
+			return err
+		}
+
+		if user.VerifiedAt == nil {
+			user, err = a.back.reregisterUser(ctx, user, req.Name, req.Password)
+			if err != nil {
+				return err
+			}
+		}
+
+		a.log(ctx).Info("register again", "user", user.ID)
+	}
+
+	var subject, body string
+
+	if user.VerifiedAt == nil {
+		token, err := a.back.putUserToken(ctx, user, TokenVerify, a.registration.VerifyTTL)
+		if err != nil {
+			return err
+		}
+
+		subject, body = verifyMail(a.publicURL, user.Name, token)
+	} else {
+		subject, body = registeredMail(user.Name)
+	}
+
+	if err := a.sendMail(ctx, user.Email, subject, body); err != nil {
+		return err
+	}
+
+	return accepted
+}
+
+// PostVerify takes back a token from a registration email, which confirms the email, so that
+// the user can log in.
+func (a *front) PostVerify(ctx context.Context, r *http.Request) any {
+	type verifyReq struct {
+		Token string `json:"ĵetono"`
+	}
+
+	req, err := DecodeBody(r, &verifyReq{})
+	if err != nil {
+		return err
+	}
+
+	if req.Token == "" {
+		return fmt.Errorf("%w: mankas ĵetono", lib.ErrHTTPBadRequest)
+	}
+
+	user0, err := a.back.useUserToken(ctx, req.Token, TokenVerify)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("%w: nevalida ĵetono", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	user1, err := a.back.verifyUser(ctx, user0)
+	if err != nil {
+		return err
+	}
+
+	a.log(ctx).Info("verified user", "user", user1.ID)
+
+	return EntityResponse{
+		Message: "retpoŝto konfirmita",
+		Entity:  apiFromUser(user1),
+	}
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func (a *front) GetRevisions(ctx context.Context, r *http.Request) any {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := a.back.getRevisions(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	out := make([]RevisionJSON, 0, len(revisions))
+
+	for _, rev := range revisions {
+		out = append(out, apiFromRevision(rev))
+	}
+
+	return EntityResponse{
+		Message: "versioj de " + string(homework.ID),
+		Entity:  out,
+	}
+}
+
+func (a *front) GetRevision(ctx context.Context, r *http.Request) any {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	number, err := strconv.Atoi(r.PathValue("revision"))
+	if err != nil {
+		return lib.ErrHTTPNotFound
+	}
+
+	revision, err := a.revision(ctx, homework, number)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "versio " + strconv.Itoa(number),
+		Entity:  apiFromRevision(revision),
+	}
+}
+
+// GetRevisionDiff compares two revisions, given as ?de=1&al=2. Without "al", the latest
+// revision is used.
+func (a *front) GetRevisionDiff(ctx context.Context, r *http.Request) any {
+	homework, err := a.visibleHomework(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+
+	from, err := strconv.Atoi(query.Get("de"))
+	if err != nil {
+		return fmt.Errorf("%w: mankas de", lib.ErrHTTPBadRequest)
+	}
+
+	to, err := a.back.latestRevision(ctx, homework.ID)
+	if err != nil {
+		return err
+	}
+
+	if s := query.Get("al"); s != "" {
+		to, err = strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%w: malbona al", lib.ErrHTTPBadRequest)
+		}
+	}
+
+	revFrom, err := a.revision(ctx, homework, from)
+	if err != nil {
+		return err
+	}
+
+	revTo, err := a.revision(ctx, homework, to)
+	if err != nil {
+		return err
+	}
+
+	parts := lib.DiffWords(revFrom.Text, revTo.Text)
+
+	out := DiffJSON{
+		From:  from,
+		To:    to,
+		Parts: make([]DiffPartJSON, 0, len(parts)),
+	}
+
+	for _, p := range parts {
+		out.Parts = append(out.Parts, DiffPartJSON{Op: string(p.Op), Text: p.Text})
+	}
+
+	return EntityResponse{
+		Message: fmt.Sprintf("diferenco de %d al %d", from, to),
+		Entity:  out,
+	}
+}
+
+func (a *front) revision(ctx context.Context, homework Homework, number int) (HomeworkRevision, error) {
+	revision, err := a.back.getRevision(ctx, homework.ID, number)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return HomeworkRevision{}, lib.ErrHTTPNotFound
+		}
+		return HomeworkRevision{}, err
+	}
+
+	return revision, nil
+}
+
+func apiFromRevision(in HomeworkRevision) RevisionJSON {
+	return RevisionJSON{
+		Number: in.Number,
+		Text:   in.Text,
+		Time:   in.CreatedAt,
+	}
+}
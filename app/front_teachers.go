@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func (a *front) GetTeachers(ctx context.Context, r *http.Request) any {
+	courseID := r.PathValue("course")
+	if courseID == "" {
+		return lib.ErrHTTPNotFound
+	}
+
+	teachers, err := a.back.getTeachersByCourse(ctx, DBID(courseID))
+	if err != nil {
+		return err
+	}
+
+	out := make([]TeacherJSON, 0, len(teachers))
+
+	for _, t := range teachers {
+		out = append(out, TeacherJSON{
+			ID: t.ID,
+			User: UserJSON{
+				ID:   t.UserID,
+				Name: t.UserX.Name,
+			},
+		})
+	}
+
+	return EntityResponse{
+		Message: "instruistoj de " + courseID,
+		Entity:  out,
+	}
+}
+
+func (a *front) PostTeachers(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	teacher0, err := DecodeBody(r, &TeacherJSON{})
+	if err != nil {
+		return err
+	}
+
+	if teacher0.Course.ID != "" && teacher0.Course.ID != course.ID {
+		return lib.ErrHTTPBadRequest
+	}
+
+	user, err := a.back.getUser(ctx, teacher0.User.ID)
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPBadRequest
+		}
+		return err
+	}
+
+	teacher1, err := a.back.addTeacherToCourse(ctx, user.ID, course.ID)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova instruisto",
+		Entity: TeacherJSON{
+			ID: teacher1.ID,
+			User: UserJSON{
+				ID:   user.ID,
+				Name: user.Name,
+			},
+			Course: CourseJSON{
+				ID: course.ID,
+			},
+		},
+	}
+}
+
+func (a *front) DeleteTeacher(ctx context.Context, r *http.Request) any {
+	course := a.courseFromContext(ctx)
+
+	teacherID := r.PathValue("teacher")
+
+	teacher, err := a.back.getTeacher(ctx, DBID(teacherID))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	if teacher.CourseID != course.ID {
+		return lib.ErrHTTPNotFound
+	}
+
+	if err := a.back.removeTeacher(ctx, teacher); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "forigita instruisto",
+		Entity:  TeacherJSON{ID: teacher.ID},
+	}
+}
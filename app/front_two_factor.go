@@ -0,0 +1,289 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// identifyPending is like identify, but only for sessions that are waiting for a second
+// factor.
+func (a *front) identifyPending(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		cookie, err := r.Cookie(a.cookie.Name)
+		if err != nil {
+			lib.SendHTTPError(w, 0, lib.ErrHTTPUnauthorized)
+			return
+		}
+
+		session, _, err := a.ident.getSession(ctx, cookie.Value)
+		if err != nil {
+			if errors.Is(err, ErrNoSession) {
+				err = lib.ErrHTTPUnauthorized
+			}
+
+			lib.SendHTTPError(w, 0, err)
+			return
+		}
+
+		if !session.Pending {
+			lib.SendHTTPError(w, 0, fmt.Errorf("%w: jam ensalutinta", lib.ErrHTTPConflict))
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(ctx, ctxKeyUser, &session.UserX)))
+	}
+}
+
+// checkSecondFactor checks a code from a TOTP app, or a recovery code, using it up.
+func (a *front) checkSecondFactor(ctx context.Context, user User, code string) error {
+	if user.TOTPEnabledAt == nil {
+		return fmt.Errorf("%w: dufaktora ne ŝaltita", lib.ErrHTTPConflict)
+	}
+
+	if isTOTPCode(code) {
+		step, ok := lib.CheckTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return fmt.Errorf("%w: malĝusta kodo", lib.ErrHTTPForbidden)
+		}
+
+		if err := a.back.useTOTPStep(ctx, user, step); err != nil {
+			if errors.Is(err, rel.ErrNotFound) {
+				return fmt.Errorf("%w: kodo jam uzita", lib.ErrHTTPForbidden)
+			}
+			return err
+		}
+
+		return nil
+	}
+
+	if err := a.back.useRecoveryCode(ctx, user, code); err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return fmt.Errorf("%w: malĝusta kodo", lib.ErrHTTPForbidden)
+		}
+		return err
+	}
+
+	a.log(ctx).Info("used recovery code", "user", user.ID)
+
+	return nil
+}
+
+// currentUser gets the user of the request again, with everything about their second factor,
+// which identify does not always have. Changing the second factor can't be done with an API
+// token, since it is about logging in.
+func (a *front) currentUser(ctx context.Context) (User, error) {
+	if a.apiTokenFromContext(ctx) != nil {
+		return User{}, fmt.Errorf("%w: ne per ĵetono", lib.ErrHTTPForbidden)
+	}
+
+	return a.back.getUser(ctx, a.userFromContext(ctx).ID)
+}
+
+type codeReq struct {
+	Code string `json:"kodo"`
+}
+
+// PostLoginCode finishes logging in, with the second factor.
+func (a *front) PostLoginCode(ctx context.Context, r *http.Request) any {
+	req, err := DecodeBody(r, &codeReq{})
+	if err != nil {
+		return err
+	}
+
+	user, err := a.back.getUser(ctx, a.userFromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkLoginCode(ctx, r, user, req.Code); err != nil {
+		var tm errTooManyTries
+		if errors.As(err, &tm) {
+			return tooManyTriesResponse(tm)
+		}
+		return err
+	}
+
+	if cookie, err := r.Cookie(a.cookie.Name); err == nil {
+		if err := a.ident.deleteSession(ctx, cookie.Value); err != nil {
+			return err
+		}
+	}
+
+	return a.newSessionResponse(ctx, user)
+}
+
+// PostTwoFactor starts setting up TOTP, with a new secret for the user's app. It only takes
+// effect once a code from the app is confirmed.
+func (a *front) PostTwoFactor(ctx context.Context, r *http.Request) any {
+	user0, err := a.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if user0.TOTPEnabledAt != nil {
+		return fmt.Errorf("%w: dufaktora jam ŝaltita", lib.ErrHTTPConflict)
+	}
+
+	secret, err := lib.MakeTOTPSecret()
+	if err != nil {
+		return err
+	}
+
+	user1, err := a.back.setTOTPSecret(ctx, user0, secret)
+	if err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "nova sekreto",
+		Entity: TwoFactorJSON{
+			Secret: secret,
+			URI:    lib.TOTPURI(a.twoFactor.Issuer, user1.Email, secret),
+		},
+	}
+}
+
+// PostTwoFactorConfirm turns on TOTP, once the user shows a code from their app. The answer
+// has recovery codes, which are never shown again.
+func (a *front) PostTwoFactorConfirm(ctx context.Context, r *http.Request) any {
+	req, err := DecodeBody(r, &codeReq{})
+	if err != nil {
+		return err
+	}
+
+	user0, err := a.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if user0.TOTPEnabledAt != nil {
+		return fmt.Errorf("%w: dufaktora jam ŝaltita", lib.ErrHTTPConflict)
+	}
+
+	if user0.TOTPSecret == "" {
+		return fmt.Errorf("%w: mankas sekreto", lib.ErrHTTPConflict)
+	}
+
+	step, ok := lib.CheckTOTP(user0.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return fmt.Errorf("%w: malĝusta kodo", lib.ErrHTTPForbidden)
+	}
+
+	codes, err := makeRecoveryCodes()
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.back.enableTOTP(ctx, user0, step, codes); err != nil {
+		return err
+	}
+
+	a.log(ctx).Info("enabled totp", "user", user0.ID)
+
+	return EntityResponse{
+		Message: "dufaktora ŝaltita",
+		Entity: TwoFactorJSON{
+			RecoveryCodes: codes,
+		},
+	}
+}
+
+// DeleteTwoFactor turns off TOTP, if the user can still give a code.
+func (a *front) DeleteTwoFactor(ctx context.Context, r *http.Request) any {
+	req, err := DecodeBody(r, &codeReq{})
+	if err != nil {
+		return err
+	}
+
+	user0, err := a.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkSecondFactor(ctx, user0, req.Code); err != nil {
+		return err
+	}
+
+	user1, err := a.back.disableTOTP(ctx, user0)
+	if err != nil {
+		return err
+	}
+
+	a.log(ctx).Info("disabled totp", "user", user1.ID)
+
+	return EntityResponse{
+		Message: "dufaktora malŝaltita",
+		Entity:  apiFromUser(user1),
+	}
+}
+
+// PostRecoveryCodes replaces all recovery codes of the user.
+func (a *front) PostRecoveryCodes(ctx context.Context, r *http.Request) any {
+	req, err := DecodeBody(r, &codeReq{})
+	if err != nil {
+		return err
+	}
+
+	user, err := a.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := a.checkSecondFactor(ctx, user, req.Code); err != nil {
+		return err
+	}
+
+	codes, err := makeRecoveryCodes()
+	if err != nil {
+		return err
+	}
+
+	if err := a.back.putRecoveryCodes(ctx, user.ID, codes); err != nil {
+		return err
+	}
+
+	return EntityResponse{
+		Message: "novaj rezervaj kodoj",
+		Entity: TwoFactorJSON{
+			RecoveryCodes: codes,
+		},
+	}
+}
+
+// DeleteUserTwoFactor lets an admin turn off TOTP for a user who has lost it all. The user is
+// logged out everywhere.
+func (a *front) DeleteUserTwoFactor(ctx context.Context, r *http.Request) any {
+	me := a.userFromContext(ctx)
+
+	user0, err := a.back.getUser(ctx, DBID(r.PathValue("user")))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return lib.ErrHTTPNotFound
+		}
+		return err
+	}
+
+	user1, err := a.back.disableTOTP(ctx, user0)
+	if err != nil {
+		return err
+	}
+
+	if err := a.ident.deleteUserSessions(ctx, user1.ID); err != nil {
+		return err
+	}
+
+	a.log(ctx).Warn("admin disabled totp", "user", user1.ID, "admin", me.ID)
+
+	return EntityResponse{
+		Message: "dufaktora malŝaltita",
+		Entity:  apiFromUser(user1),
+	}
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// checkGrades makes sure that some grades give points for every criterion of a rubric, and
+// for nothing else, each within the criterion's scale.
+func checkGrades(rubric Rubric, grades []Grade) error {
+	criteria := map[DBID]RubricCriterion{}
+	for _, c := range rubric.Criteria {
+		criteria[c.ID] = c
+	}
+
+	seen := map[DBID]bool{}
+
+	for i, g := range grades {
+		c, ok := criteria[g.CriterionID]
+		if !ok {
+			return fmt.Errorf("%w: nota %d: kriterio ne en rubriko", lib.ErrHTTPBadRequest, i)
+		}
+
+		if seen[g.CriterionID] {
+			return fmt.Errorf("%w: nota %d: kriterio ripetita", lib.ErrHTTPBadRequest, i)
+		}
+
+		if g.Points < 0 || g.Points > c.MaxPoints {
+			return fmt.Errorf("%w: nota %d: poentoj devas esti inter 0 kaj %d", lib.ErrHTTPBadRequest, i, c.MaxPoints)
+		}
+
+		seen[g.CriterionID] = true
+	}
+
+	if len(seen) != len(criteria) {
+		return fmt.Errorf("%w: mankas notoj por iuj kriterioj", lib.ErrHTTPBadRequest)
+	}
+
+	return nil
+}
+
+// returnedGrades are the grades that the learner can see, which are only those given before
+// the homework was returned, while it is still returned.
+func returnedGrades(homework Homework, grades []Grade) []Grade {
+	if homework.Status != HomeworkReturned || homework.ReturnedAt == nil {
+		return nil
+	}
+
+	out := make([]Grade, 0, len(grades))
+
+	for _, g := range grades {
+		if !g.CreatedAt.After(*homework.ReturnedAt) {
+			out = append(out, g)
+		}
+	}
+
+	return out
+}
+
+// sumGrades adds up the points of some grades, and the most they could have been.
+func sumGrades(grades []Grade) (total, most int) {
+	for _, g := range grades {
+		total += g.Points
+		most += g.CriterionX.MaxPoints
+	}
+
+	return total, most
+}
+
+// buildGradebook makes a table of learners and assignments. For each learner and assignment,
+// the score is from the most recently submitted homework that has been graded.
+func buildGradebook(assignments []Assignment, learners []Learner, homeworks []Homework, grades []Grade) GradebookJSON {
+	byHomework := map[DBID][]Grade{}
+	for _, g := range grades {
+		byHomework[g.HomeworkID] = append(byHomework[g.HomeworkID], g)
+	}
+
+	type cell struct {
+		learner, assignment DBID
+	}
+
+	latest := map[cell]Homework{}
+
+	for _, h := range homeworks {
+		if h.AssignmentID == nil || len(byHomework[h.ID]) == 0 {
+			continue
+		}
+
+		k := cell{h.LearnerID, *h.AssignmentID}
+
+		if prev, ok := latest[k]; ok && !submittedAfter(h, prev) {
+			continue
+		}
+
+		latest[k] = h
+	}
+
+	out := GradebookJSON{
+		Assignments: make([]AssignmentJSON, 0, len(assignments)),
+		Learners:    make([]GradebookRowJSON, 0, len(learners)),
+	}
+
+	for _, as := range assignments {
+		out.Assignments = append(out.Assignments, apiFromAssignment(as))
+	}
+
+	for _, l := range learners {
+		row := GradebookRowJSON{
+			Learner: UserJSON{ID: l.UserID, Name: l.UserX.Name, Email: l.UserX.Email},
+			Scores:  make([]*ScoreJSON, len(assignments)),
+		}
+
+		for i, as := range assignments {
+			h, ok := latest[cell{l.ID, as.ID}]
+			if !ok {
+				continue
+			}
+
+			total, most := sumGrades(byHomework[h.ID])
+
+			row.Scores[i] = &ScoreJSON{
+				Homework:  h.ID,
+				Total:     total,
+				MaxPoints: most,
+			}
+		}
+
+		out.Learners = append(out.Learners, row)
+	}
+
+	return out
+}
+
+func submittedAfter(a, b Homework) bool {
+	if a.SubmittedAt == nil || b.SubmittedAt == nil {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+
+	return a.SubmittedAt.After(*b.SubmittedAt)
+}
+
+// csvSafe stops text that people typed from being taken as a formula, when the CSV is opened
+// in a spreadsheet.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+// writeGradebookCSV writes a gradebook with one line per learner and one column per assignment.
+func writeGradebookCSV(w io.Writer, gradebook GradebookJSON) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"nomo", "retpoŝto"}
+	for _, as := range gradebook.Assignments {
+		header = append(header, csvSafe(as.Lesson.Name+" ("+string(as.ID)+")"))
+	}
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range gradebook.Learners {
+		line := []string{csvSafe(row.Learner.Name), csvSafe(row.Learner.Email)}
+
+		for _, s := range row.Scores {
+			if s == nil {
+				line = append(line, "")
+				continue
+			}
+
+			line = append(line, strconv.Itoa(s.Total)+"/"+strconv.Itoa(s.MaxPoints))
+		}
+
+		if err := cw.Write(line); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
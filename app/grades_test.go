@@ -0,0 +1,92 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckGrades(t *testing.T) {
+	rubric := Rubric{
+		Criteria: []RubricCriterion{
+			{ID: "kr-1", MaxPoints: 5},
+			{ID: "kr-2", MaxPoints: 3},
+		},
+	}
+
+	assert.NoError(t, checkGrades(rubric, []Grade{{CriterionID: "kr-1", Points: 5}, {CriterionID: "kr-2"}}))
+
+	assert.Error(t, checkGrades(rubric, []Grade{{CriterionID: "kr-1", Points: 5}}))
+	assert.Error(t, checkGrades(rubric, []Grade{{CriterionID: "kr-1", Points: 6}, {CriterionID: "kr-2"}}))
+	assert.Error(t, checkGrades(rubric, []Grade{{CriterionID: "kr-1"}, {CriterionID: "kr-1"}}))
+	assert.Error(t, checkGrades(rubric, []Grade{{CriterionID: "kr-1"}, {CriterionID: "kr-3"}}))
+}
+
+func TestBuildGradebook(t *testing.T) {
+	t1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	task := DBID("t-1")
+
+	assignments := []Assignment{{ID: task, LessonX: Lesson{Name: "Unua"}}}
+	learners := []Learner{
+		{ID: "l-1", UserID: "u-1", UserX: User{Name: "Ana", Email: "ana@example.com"}},
+		{ID: "l-2", UserID: "u-2", UserX: User{Name: "Bob", Email: "bob@example.com"}},
+	}
+	homeworks := []Homework{
+		{ID: "ht-1", LearnerID: "l-1", AssignmentID: &task, SubmittedAt: &t1},
+		{ID: "ht-2", LearnerID: "l-1", AssignmentID: &task, SubmittedAt: &t2},
+		{ID: "ht-3", LearnerID: "l-2", AssignmentID: &task, SubmittedAt: &t1},
+	}
+	grades := []Grade{
+		{HomeworkID: "ht-1", Points: 1, CriterionX: RubricCriterion{MaxPoints: 5}},
+		{HomeworkID: "ht-2", Points: 4, CriterionX: RubricCriterion{MaxPoints: 5}},
+		{HomeworkID: "ht-2", Points: 2, CriterionX: RubricCriterion{MaxPoints: 3}},
+	}
+
+	gradebook := buildGradebook(assignments, learners, homeworks, grades)
+
+	assert.Len(t, gradebook.Learners, 2)
+	assert.Equal(t, &ScoreJSON{Homework: "ht-2", Total: 6, MaxPoints: 8}, gradebook.Learners[0].Scores[0])
+	assert.Nil(t, gradebook.Learners[1].Scores[0])
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, writeGradebookCSV(buf, gradebook))
+	assert.Equal(t, "nomo,retpoŝto,Unua (t-1)\nAna,ana@example.com,6/8\nBob,bob@example.com,\n", buf.String())
+}
+
+func TestCSVSafe(t *testing.T) {
+	assert.Equal(t, "Ana", csvSafe("Ana"))
+	assert.Equal(t, "", csvSafe(""))
+	assert.Equal(t, "'=HYPERLINK(\"x\")", csvSafe("=HYPERLINK(\"x\")"))
+	assert.Equal(t, "'+1", csvSafe("+1"))
+	assert.Equal(t, "'-1", csvSafe("-1"))
+	assert.Equal(t, "'@SUM(A1)", csvSafe("@SUM(A1)"))
+	assert.Equal(t, "'\tx", csvSafe("\tx"))
+	assert.Equal(t, "'\rx", csvSafe("\rx"))
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, writeGradebookCSV(buf, GradebookJSON{
+		Learners: []GradebookRowJSON{{Learner: UserJSON{Name: "=1+1", Email: "a@example.com"}}},
+	}))
+	assert.Equal(t, "nomo,retpoŝto\n'=1+1,a@example.com\n", buf.String())
+}
+
+func TestReturnedGrades(t *testing.T) {
+	returned := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	grades := []Grade{
+		{ID: "no-1", CreatedAt: returned.Add(-time.Hour)},
+		{ID: "no-2", CreatedAt: returned.Add(time.Hour)},
+	}
+
+	out := returnedGrades(Homework{Status: HomeworkReturned, ReturnedAt: &returned}, grades)
+	assert.Len(t, out, 1)
+	assert.Equal(t, DBID("no-1"), out[0].ID)
+
+	// resubmitted, so nothing until it is returned again
+	assert.Empty(t, returnedGrades(Homework{Status: HomeworkCorrected, ReturnedAt: &returned}, grades))
+	assert.Empty(t, returnedGrades(Homework{Status: HomeworkInReview}, grades))
+}
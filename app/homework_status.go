@@ -0,0 +1,136 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+type HomeworkStatus string
+
+const (
+	// HomeworkDraft is saved by the learner, but not yet seen by teachers.
+	HomeworkDraft HomeworkStatus = "malneto"
+	// HomeworkSubmitted is waiting for a teacher.
+	HomeworkSubmitted HomeworkStatus = "ensendita"
+	// HomeworkInReview has been claimed by a teacher.
+	HomeworkInReview HomeworkStatus = "korektata"
+	// HomeworkCorrected has corrections, but the learner can't see them yet.
+	HomeworkCorrected HomeworkStatus = "korektita"
+	// HomeworkReturned has been given back to the learner.
+	HomeworkReturned HomeworkStatus = "redonita"
+)
+
+var homeworkStatuses = []HomeworkStatus{
+	HomeworkDraft,
+	HomeworkSubmitted,
+	HomeworkInReview,
+	HomeworkCorrected,
+	HomeworkReturned,
+}
+
+type homeworkTransition struct {
+	from, to HomeworkStatus
+	teacher  bool
+}
+
+// homeworkTransitions are all the allowed status changes, and who can make them.
+var homeworkTransitions = []homeworkTransition{
+	{HomeworkDraft, HomeworkSubmitted, false},
+	{HomeworkSubmitted, HomeworkInReview, true},
+	{HomeworkInReview, HomeworkSubmitted, true},
+	{HomeworkInReview, HomeworkCorrected, true},
+	{HomeworkCorrected, HomeworkReturned, true},
+	{HomeworkReturned, HomeworkSubmitted, false},
+}
+
+// checkHomeworkTransition says whether someone, teacher or learner, can move homework
+// from one status to another.
+func checkHomeworkTransition(from, to HomeworkStatus, teacher bool) error {
+	for _, t := range homeworkTransitions {
+		if t.from == from && t.to == to {
+			if t.teacher != teacher {
+				return fmt.Errorf("%w: ne eblas ŝanĝi de %s al %s", lib.ErrHTTPForbidden, from, to)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: ne eblas ŝanĝi de %s al %s", lib.ErrHTTPConflict, from, to)
+}
+
+// claimedStatuses are those in which homework belongs to the teacher who claimed it, until it
+// is returned.
+var claimedStatuses = []HomeworkStatus{HomeworkInReview, HomeworkCorrected}
+
+// checkReviewer says whether someone can work on homework that has been claimed, which only the
+// teacher who claimed it can do, unless they can override, as the owner of the course can.
+func checkReviewer(homework Homework, by DBID, override bool) error {
+	if !slices.Contains(claimedStatuses, homework.Status) || override {
+		return nil
+	}
+
+	if homework.ReviewerID == nil || *homework.ReviewerID != by {
+		return fmt.Errorf("%w: alia instruisto korektas", lib.ErrHTTPForbidden)
+	}
+
+	return nil
+}
+
+// homeworkStatusChange works out what changes when homework moves to a new status, both in the
+// homework and in the database. Going back to submitted, as when a teacher lets go of a claim,
+// keeps the time the learner submitted, so the homework keeps its place in the queue; only
+// submitHomework sets that.
+func homeworkStatusChange(homework Homework, to HomeworkStatus, by DBID, now time.Time) (Homework, []rel.Mutate) {
+	mutates := []rel.Mutate{
+		rel.Set("status", to),
+		rel.Set("updated_at", now),
+	}
+
+	switch to {
+	case HomeworkSubmitted:
+		mutates = append(mutates, rel.Set("claimed_at", nil), rel.Set("reviewer", nil))
+		homework.ClaimedAt = nil
+		homework.ReviewerID = nil
+	case HomeworkInReview:
+		mutates = append(mutates, rel.Set("claimed_at", now), rel.Set("reviewer", by))
+		homework.ClaimedAt = &now
+		homework.ReviewerID = &by
+	case HomeworkCorrected:
+		mutates = append(mutates, rel.Set("corrected_at", now))
+		homework.CorrectedAt = &now
+	case HomeworkReturned:
+		mutates = append(mutates, rel.Set("returned_at", now))
+		homework.ReturnedAt = &now
+	}
+
+	homework.Status = to
+	homework.UpdatedAt = now
+
+	return homework, mutates
+}
+
+// parseHomeworkStatuses reads a filter like "ensendita,korektata".
+func parseHomeworkStatuses(in string) ([]HomeworkStatus, error) {
+	if in == "" {
+		return nil, nil
+	}
+
+	var out []HomeworkStatus
+
+	for _, s := range strings.Split(in, ",") {
+		status := HomeworkStatus(strings.TrimSpace(s))
+		if !slices.Contains(homeworkStatuses, status) {
+			return nil, fmt.Errorf("%w: nekonata stato %q", lib.ErrHTTPBadRequest, status)
+		}
+
+		out = append(out, status)
+	}
+
+	return out, nil
+}
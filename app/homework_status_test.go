@@ -0,0 +1,60 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestCheckHomeworkTransition(t *testing.T) {
+	assert.NoError(t, checkHomeworkTransition(HomeworkDraft, HomeworkSubmitted, false))
+	assert.NoError(t, checkHomeworkTransition(HomeworkSubmitted, HomeworkInReview, true))
+	assert.NoError(t, checkHomeworkTransition(HomeworkReturned, HomeworkSubmitted, false))
+
+	assert.ErrorIs(t, checkHomeworkTransition(HomeworkSubmitted, HomeworkInReview, false), lib.ErrHTTPForbidden)
+	assert.ErrorIs(t, checkHomeworkTransition(HomeworkDraft, HomeworkReturned, true), lib.ErrHTTPConflict)
+}
+
+func TestCheckReviewer(t *testing.T) {
+	reviewer := DBID("u-1")
+	homework := Homework{Status: HomeworkInReview, ReviewerID: &reviewer}
+
+	assert.NoError(t, checkReviewer(homework, "u-1", false))
+	assert.ErrorIs(t, checkReviewer(homework, "u-2", false), lib.ErrHTTPForbidden)
+	assert.NoError(t, checkReviewer(homework, "u-2", true))
+
+	homework.Status = HomeworkCorrected
+	assert.ErrorIs(t, checkReviewer(homework, "u-2", false), lib.ErrHTTPForbidden)
+
+	assert.NoError(t, checkReviewer(Homework{Status: HomeworkSubmitted}, "u-2", false))
+}
+
+func TestParseHomeworkStatuses(t *testing.T) {
+	s, err := parseHomeworkStatuses("ensendita, korektata")
+	assert.NoError(t, err)
+	assert.Equal(t, []HomeworkStatus{HomeworkSubmitted, HomeworkInReview}, s)
+
+	_, err = parseHomeworkStatuses("ensendita,nenio")
+	assert.Error(t, err)
+}
+
+func TestHomeworkStatusChange(t *testing.T) {
+	submitted := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	homework := Homework{Status: HomeworkSubmitted, SubmittedAt: &submitted}
+
+	claimed, _ := homeworkStatusChange(homework, HomeworkInReview, "u-1", submitted.Add(time.Hour))
+	assert.Equal(t, DBID("u-1"), *claimed.ReviewerID)
+	assert.NotNil(t, claimed.ClaimedAt)
+
+	released, mutates := homeworkStatusChange(claimed, HomeworkSubmitted, "u-1", submitted.Add(2*time.Hour))
+	assert.Equal(t, HomeworkSubmitted, released.Status)
+	assert.Equal(t, submitted, *released.SubmittedAt)
+	assert.Nil(t, released.ReviewerID)
+	assert.Nil(t, released.ClaimedAt)
+
+	for _, m := range mutates {
+		assert.NotEqual(t, "submitted_at", m.Field)
+	}
+}
@@ -1,52 +1,128 @@
 package app
 
 import (
-	"sync"
+	"context"
+	"time"
 
 	"github.com/undeconstructed/skribserv/lib"
 )
 
-type Session struct {
-	user User
-}
-
 type Authenticator struct {
-	sync.RWMutex
-	sessions map[string]*Session
+	store SessionStore
+	ttl   time.Duration
+	now   func() time.Time
+	log   lib.MakeContextLogger
 }
 
-func NewAuthenticator() *Authenticator {
+func NewAuthenticator(store SessionStore, ttl time.Duration, log lib.MakeContextLogger) *Authenticator {
 	return &Authenticator{
-		sessions: map[string]*Session{},
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+		log:   log,
 	}
 }
 
-func (ai *Authenticator) putSession(user User) (string, error) {
-	id := lib.MakeRandomID("seanco", 10)
+// pendingSessionTTL is how long someone has to give their second factor, after their password.
+const pendingSessionTTL = 5 * time.Minute
+
+// putSession makes a new session for a user, returning the token that the user must present
+// to use it. Only a hash of the token is stored.
+func (ai *Authenticator) putSession(ctx context.Context, user User) (Session, string, error) {
+	return ai.newSession(ctx, user, false, ai.ttl)
+}
+
+// putPendingSession makes a short session for a user who has given their password, but still
+// has to give a second factor.
+func (ai *Authenticator) putPendingSession(ctx context.Context, user User) (Session, string, error) {
+	return ai.newSession(ctx, user, true, pendingSessionTTL)
+}
+
+func (ai *Authenticator) newSession(ctx context.Context, user User, pending bool, ttl time.Duration) (Session, string, error) {
+	token, err := lib.MakeSecretToken("seanco", 32)
+	if err != nil {
+		return Session{}, "", err
+	}
+
+	now := ai.now()
 
-	ai.Lock()
-	defer ai.Unlock()
+	session := Session{
+		ID:        DBID(lib.HashToken(token)),
+		UserID:    user.ID,
+		UserX:     user,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		Pending:   pending,
+	}
 
-	ai.sessions[id] = &Session{user: user}
+	if err := ai.store.PutSession(ctx, session); err != nil {
+		return Session{}, "", err
+	}
 
-	return id, nil
+	return session, token, nil
 }
 
-func (ai *Authenticator) getSessionUser(id string) (*User, error) {
-	ai.Lock()
-	defer ai.Unlock()
+// getSession finds a live session. Sessions slide, so if one is past half way to expiring
+// then it is extended, and renewed is true, so that the cookie can be sent again. Pending
+// sessions don't slide. Sessions of deleted users are treated as expired.
+func (ai *Authenticator) getSession(ctx context.Context, token string) (session Session, renewed bool, err error) {
+	session, err = ai.store.GetSession(ctx, DBID(lib.HashToken(token)))
+	if err != nil {
+		return Session{}, false, err
+	}
+
+	now := ai.now()
+
+	if !now.Before(session.ExpiresAt) || session.UserX.DeletedAt != nil {
+		if err := ai.store.DeleteSession(ctx, session.ID); err != nil {
+			return Session{}, false, err
+		}
+
+		return Session{}, false, ErrNoSession
+	}
+
+	if !session.Pending && session.ExpiresAt.Sub(now) < ai.ttl/2 {
+		expires := now.Add(ai.ttl)
+
+		if err := ai.store.ExtendSession(ctx, session.ID, expires); err != nil {
+			return Session{}, false, err
+		}
 
-	u, ok := ai.sessions[id]
-	if !ok {
-		return nil, ErrNoSession
+		session.ExpiresAt = expires
+		renewed = true
 	}
 
-	return &u.user, nil
+	return session, renewed, nil
 }
 
-func (ai *Authenticator) deleteSession(id string) {
-	ai.Lock()
-	defer ai.Unlock()
+func (ai *Authenticator) deleteSession(ctx context.Context, token string) error {
+	return ai.store.DeleteSession(ctx, DBID(lib.HashToken(token)))
+}
+
+// deleteUserSessions logs a user out everywhere.
+func (ai *Authenticator) deleteUserSessions(ctx context.Context, user DBID) error {
+	return ai.store.DeleteUserSessions(ctx, user)
+}
 
-	delete(ai.sessions, id)
+// sweep deletes expired sessions every so often, until the context ends.
+func (ai *Authenticator) sweep(ctx context.Context, every time.Duration) {
+	tick := time.NewTicker(every)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			n, err := ai.store.DeleteExpiredSessions(ctx, ai.now())
+			if err != nil {
+				ai.log(ctx).Error("sweep sessions", "err", err)
+				continue
+			}
+
+			if n > 0 {
+				ai.log(ctx).Debug("swept sessions", "count", n)
+			}
+		}
+	}
 }
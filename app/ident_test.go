@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestAuthenticatorExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ai := NewAuthenticator(NewMemorySessionStore(), time.Hour, lib.SubLog(slog.Default()))
+	ai.now = func() time.Time { return now }
+
+	s0, token, err := ai.putSession(ctx, User{ID: "u-1"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, DBID(token), s0.ID)
+
+	s1, renewed, err := ai.getSession(ctx, token)
+	assert.NoError(t, err)
+	assert.False(t, renewed)
+	assert.Equal(t, DBID("u-1"), s1.UserX.ID)
+
+	now = now.Add(45 * time.Minute)
+
+	s2, renewed, err := ai.getSession(ctx, token)
+	assert.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, now.Add(time.Hour), s2.ExpiresAt)
+
+	now = now.Add(2 * time.Hour)
+
+	_, _, err = ai.getSession(ctx, token)
+	assert.ErrorIs(t, err, ErrNoSession)
+}
+
+func TestAuthenticatorPending(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ai := NewAuthenticator(NewMemorySessionStore(), time.Hour, lib.SubLog(slog.Default()))
+	ai.now = func() time.Time { return now }
+
+	_, token, err := ai.putPendingSession(ctx, User{ID: "u-1"})
+	assert.NoError(t, err)
+
+	now = now.Add(4 * time.Minute)
+
+	s1, renewed, err := ai.getSession(ctx, token)
+	assert.NoError(t, err)
+	assert.False(t, renewed)
+	assert.True(t, s1.Pending)
+
+	now = now.Add(2 * time.Minute)
+
+	_, _, err = ai.getSession(ctx, token)
+	assert.ErrorIs(t, err, ErrNoSession)
+}
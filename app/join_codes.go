@@ -0,0 +1,29 @@
+package app
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// checkJoinCode says whether a code given by a user would let them join a course now.
+func checkJoinCode(course Course, code string, now time.Time) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if course.JoinCode == "" || subtle.ConstantTimeCompare([]byte(code), []byte(course.JoinCode)) != 1 {
+		return fmt.Errorf("%w: malĝusta kodo", lib.ErrHTTPForbidden)
+	}
+
+	if course.JoinExpiresAt != nil && !now.Before(*course.JoinExpiresAt) {
+		return fmt.Errorf("%w: kodo eksvalidiĝis", lib.ErrHTTPForbidden)
+	}
+
+	if course.JoinMaxUses > 0 && course.JoinUses >= course.JoinMaxUses {
+		return fmt.Errorf("%w: kodo eluzita", lib.ErrHTTPForbidden)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestCheckJoinCode(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	course := Course{JoinCode: "ABCD2345", JoinExpiresAt: &later, JoinMaxUses: 2, JoinUses: 1}
+
+	assert.NoError(t, checkJoinCode(course, "abcd2345 ", now))
+
+	assert.ErrorIs(t, checkJoinCode(course, "ABCD2346", now), lib.ErrHTTPForbidden)
+	assert.ErrorIs(t, checkJoinCode(course, "ABCD2345", later), lib.ErrHTTPForbidden)
+	assert.ErrorIs(t, checkJoinCode(Course{}, "", now), lib.ErrHTTPForbidden)
+
+	course.JoinUses = 2
+	assert.ErrorIs(t, checkJoinCode(course, "ABCD2345", now), lib.ErrHTTPForbidden)
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+type LearnerStatus string
+
+const (
+	// LearnerActive is taking part in the course.
+	LearnerActive LearnerStatus = "aktiva"
+	// LearnerPaused has stopped for a while, but keeps their place.
+	LearnerPaused LearnerStatus = "paŭzanta"
+	// LearnerCompleted has finished the course.
+	LearnerCompleted LearnerStatus = "fininta"
+	// LearnerDropped has left the course. Their homework stays, but can't be changed.
+	LearnerDropped LearnerStatus = "forlasinta"
+)
+
+// waitingStatuses are those of learners who can still be waiting for a place.
+var waitingStatuses = []string{string(LearnerActive), string(LearnerPaused)}
+
+type learnerTransition struct {
+	from, to LearnerStatus
+	// self is whether the learner can do it, and not only those who manage the course
+	self bool
+}
+
+// learnerTransitions are all the allowed status changes, and who can make them.
+var learnerTransitions = []learnerTransition{
+	{LearnerActive, LearnerPaused, true},
+	{LearnerPaused, LearnerActive, true},
+	{LearnerActive, LearnerCompleted, false},
+	{LearnerCompleted, LearnerActive, false},
+	{LearnerActive, LearnerDropped, true},
+	{LearnerPaused, LearnerDropped, true},
+	{LearnerDropped, LearnerActive, false},
+}
+
+// checkLearnerTransition says whether someone, manager or the learner, can move a learner
+// from one status to another.
+func checkLearnerTransition(from, to LearnerStatus, manager bool) error {
+	for _, t := range learnerTransitions {
+		if t.from == from && t.to == to {
+			if !t.self && !manager {
+				return fmt.Errorf("%w: ne eblas ŝanĝi de %s al %s", lib.ErrHTTPForbidden, from, to)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: ne eblas ŝanĝi de %s al %s", lib.ErrHTTPConflict, from, to)
+}
+
+// learnerStatusTime is the column that records when a learner entered a status, if any.
+// Becoming active again only counts as enrolling if the learner had left; coming back from a
+// pause keeps the date they first enrolled.
+func learnerStatusTime(from, to LearnerStatus) string {
+	switch to {
+	case LearnerPaused:
+		return "paused_at"
+	case LearnerCompleted:
+		return "completed_at"
+	case LearnerDropped:
+		return "dropped_at"
+	case LearnerActive:
+		if from == LearnerCompleted || from == LearnerDropped {
+			return "enrolled_at"
+		}
+	}
+
+	return ""
+}
+
+// checkRejoin says whether a user can join a course again by themselves, which they can't if
+// someone else took them out of it.
+func checkRejoin(old Learner, user DBID) error {
+	if old.DroppedByID != nil && *old.DroppedByID != user {
+		return fmt.Errorf("%w: forigita de la kurso", lib.ErrHTTPForbidden)
+	}
+
+	return nil
+}
+
+// checkLearnerActive makes sure that the learner's homework can still be changed.
+func checkLearnerActive(learner Learner) error {
+	if learner.Status == LearnerDropped {
+		return fmt.Errorf("%w: lernanto forlasis la kurson", lib.ErrHTTPConflict)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestCheckLearnerTransition(t *testing.T) {
+	assert.NoError(t, checkLearnerTransition(LearnerActive, LearnerPaused, false))
+	assert.NoError(t, checkLearnerTransition(LearnerPaused, LearnerDropped, false))
+	assert.NoError(t, checkLearnerTransition(LearnerActive, LearnerCompleted, true))
+
+	assert.ErrorIs(t, checkLearnerTransition(LearnerActive, LearnerCompleted, false), lib.ErrHTTPForbidden)
+	assert.ErrorIs(t, checkLearnerTransition(LearnerDropped, LearnerActive, false), lib.ErrHTTPForbidden)
+	assert.ErrorIs(t, checkLearnerTransition(LearnerCompleted, LearnerPaused, true), lib.ErrHTTPConflict)
+}
+
+func TestLearnerStatusTime(t *testing.T) {
+	assert.Equal(t, "paused_at", learnerStatusTime(LearnerActive, LearnerPaused))
+	assert.Equal(t, "", learnerStatusTime(LearnerPaused, LearnerActive))
+	assert.Equal(t, "enrolled_at", learnerStatusTime(LearnerDropped, LearnerActive))
+	assert.Equal(t, "dropped_at", learnerStatusTime(LearnerPaused, LearnerDropped))
+}
+
+func TestCheckLearnerActive(t *testing.T) {
+	assert.NoError(t, checkLearnerActive(Learner{Status: LearnerPaused}))
+	assert.ErrorIs(t, checkLearnerActive(Learner{Status: LearnerDropped}), lib.ErrHTTPConflict)
+}
+
+func TestCheckRejoin(t *testing.T) {
+	self, teacher := DBID("u-1"), DBID("u-2")
+
+	assert.NoError(t, checkRejoin(Learner{UserID: self, Status: LearnerDropped}, self))
+	assert.NoError(t, checkRejoin(Learner{UserID: self, Status: LearnerDropped, DroppedByID: &self}, self))
+	assert.ErrorIs(t, checkRejoin(Learner{UserID: self, Status: LearnerDropped, DroppedByID: &teacher}, self), lib.ErrHTTPForbidden)
+}
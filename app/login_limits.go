@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/undeconstructed/skribserv/config"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// loginLimits slows down password guessing, both from one address and at one account. This is
+// only in memory; accounts are also locked in the database after too many failures.
+type loginLimits struct {
+	ip      *lib.Backoff
+	account *lib.Backoff
+	cfg     config.LoginLimitsConfig
+}
+
+func newLoginLimits(cfg config.LoginLimitsConfig) *loginLimits {
+	return &loginLimits{
+		ip:      lib.NewBackoff(cfg.FreeFailures, cfg.BaseDelay, cfg.MaxDelay),
+		account: lib.NewBackoff(cfg.FreeFailures, cfg.BaseDelay, cfg.MaxDelay),
+		cfg:     cfg,
+	}
+}
+
+// wait says how long a login must wait, for whichever of the address and account is slower.
+func (l *loginLimits) wait(ip, email string, now time.Time) time.Duration {
+	return max(l.ip.Wait(ip, now), l.account.Wait(accountKey(email), now))
+}
+
+// fail counts a failed login against both the address and the account.
+func (l *loginLimits) fail(ip, email string, now time.Time) time.Duration {
+	return max(l.ip.Fail(ip, now), l.account.Fail(accountKey(email), now))
+}
+
+// succeed forgets failures at an account. Failures from the address still count, or else
+// someone could guess at many accounts, while logging in to their own in between.
+func (l *loginLimits) succeed(email string) {
+	l.account.Reset(accountKey(email))
+}
+
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// remoteIP is the address that a request came from, without the port.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// errTooManyTries is for a login that came before the wait was over.
+type errTooManyTries struct {
+	wait time.Duration
+}
+
+func (e errTooManyTries) Error() string {
+	return fmt.Sprintf("%d tro da provoj", http.StatusTooManyRequests)
+}
+
+func (e errTooManyTries) StatusCode() int {
+	return http.StatusTooManyRequests
+}
+
+// retryAfter is the wait in whole seconds, for the Retry-After header.
+func (e errTooManyTries) retryAfter() string {
+	return fmt.Sprint(int((e.wait + time.Second - 1) / time.Second))
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/config"
+)
+
+func TestLoginLimits(t *testing.T) {
+	l := newLoginLimits(config.LoginLimitsConfig{
+		FreeFailures: 1,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+	})
+
+	now := time.Now()
+
+	l.fail("10.0.0.1", "ludoviko@example.com", now)
+	assert.Equal(t, time.Duration(0), l.wait("10.0.0.1", "ludoviko@example.com", now))
+
+	l.fail("10.0.0.1", "ludoviko@example.com", now)
+	assert.Equal(t, time.Second, l.wait("10.0.0.1", "ludoviko@example.com", now))
+
+	// the account is slowed down from anywhere
+	assert.Equal(t, time.Second, l.wait("10.0.0.2", " Ludoviko@Example.com", now))
+	// and the address for any account
+	assert.Equal(t, time.Second, l.wait("10.0.0.1", "klara@example.com", now))
+
+	l.succeed("ludoviko@example.com")
+	assert.Equal(t, time.Duration(0), l.wait("10.0.0.2", "ludoviko@example.com", now))
+	assert.Equal(t, time.Second, l.wait("10.0.0.1", "ludoviko@example.com", now))
+}
+
+func TestRemoteIP(t *testing.T) {
+	assert.Equal(t, "10.0.0.1", remoteIP(&http.Request{RemoteAddr: "10.0.0.1:1234"}))
+	assert.Equal(t, "::1", remoteIP(&http.Request{RemoteAddr: "[::1]:1234"}))
+	assert.Equal(t, "pipe", remoteIP(&http.Request{RemoteAddr: "pipe"}))
+}
+
+func TestErrTooManyTries(t *testing.T) {
+	assert.Equal(t, "2", errTooManyTries{wait: 1500 * time.Millisecond}.retryAfter())
+	assert.Equal(t, "1", errTooManyTries{wait: time.Second}.retryAfter())
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// oidcLoginTTL is how long someone has to log in at the provider and come back.
+const oidcLoginTTL = 10 * time.Minute
+
+// oidcUser makes a new user from what the provider says about someone. Only an email that the
+// provider has checked can be trusted to say who they are.
+func oidcUser(claims lib.OIDCClaims) (User, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return User{}, fmt.Errorf("%w: retpoŝto ne konfirmita ĉe provizanto", lib.ErrHTTPForbidden)
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	now := time.Now()
+
+	return User{
+		Name:       name,
+		Email:      claims.Email,
+		VerifiedAt: &now,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestOIDCUser(t *testing.T) {
+	user, err := oidcUser(lib.OIDCClaims{Email: "ludoviko@example.com", EmailVerified: true, Name: " Ludoviko "})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ludoviko", user.Name)
+	assert.Equal(t, "ludoviko@example.com", user.Email)
+	assert.NotNil(t, user.VerifiedAt)
+
+	user, err = oidcUser(lib.OIDCClaims{Email: "ludoviko@example.com", EmailVerified: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "ludoviko", user.Name)
+
+	_, err = oidcUser(lib.OIDCClaims{Email: "ludoviko@example.com"})
+	assert.ErrorIs(t, err, lib.ErrHTTPForbidden)
+
+	_, err = oidcUser(lib.OIDCClaims{EmailVerified: true})
+	assert.ErrorIs(t, err, lib.ErrHTTPForbidden)
+}
@@ -0,0 +1,97 @@
+package app
+
+import "slices"
+
+// Role is what a user is. Admins are admins everywhere, but other roles are for a course, and
+// a user can have more than one in the same course.
+type Role string
+
+const (
+	RoleAdmin    Role = "admino"
+	RoleOwner    Role = "posedanto"
+	RoleTeacher  Role = "instruisto"
+	RoleLearner  Role = "lernanto"
+	RoleObserver Role = "observanto"
+)
+
+// Permission is something that a user may do, if one of their roles allows it.
+type Permission string
+
+const (
+	// PermManageUsers is for seeing and changing all users.
+	PermManageUsers Permission = "administri-uzantojn"
+	// PermCreateCourse is for making new courses.
+	PermCreateCourse Permission = "krei-kurson"
+	// PermManageStaff is for choosing who teaches and observes a course.
+	PermManageStaff Permission = "elekti-instruistojn"
+	// PermManageCourse is for changing a course, its lessons and assignments, and who learns.
+	PermManageCourse Permission = "administri-kurson"
+	// PermReview is for correcting and grading homework.
+	PermReview Permission = "korekti"
+	// PermViewProgress is for seeing the homework and grades of all learners.
+	PermViewProgress Permission = "vidi-progreson"
+	// PermViewCourse is for anyone who takes part in a course.
+	PermViewCourse Permission = "vidi-kurson"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermManageUsers, PermCreateCourse,
+		PermManageStaff, PermManageCourse, PermReview, PermViewProgress, PermViewCourse,
+	},
+	RoleOwner: {
+		PermManageStaff, PermManageCourse, PermReview, PermViewProgress, PermViewCourse,
+	},
+	RoleTeacher: {
+		PermManageCourse, PermReview, PermViewProgress, PermViewCourse,
+	},
+	RoleObserver: {
+		PermViewProgress, PermViewCourse,
+	},
+	RoleLearner: {
+		PermViewCourse,
+	},
+}
+
+// rolesAllow says whether any of some roles has a permission.
+func rolesAllow(roles []Role, perm Permission) bool {
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// userRoles are the roles that a user has everywhere.
+func userRoles(user *User) []Role {
+	if user.Admin {
+		return []Role{RoleAdmin}
+	}
+
+	return nil
+}
+
+// courseRoles works out the roles of a user in a course, from what is known about them.
+func courseRoles(user *User, course Course, teacher, observer, learner bool) []Role {
+	roles := userRoles(user)
+
+	if course.OwnerID == user.ID {
+		roles = append(roles, RoleOwner)
+	}
+
+	if teacher {
+		roles = append(roles, RoleTeacher)
+	}
+
+	if observer {
+		roles = append(roles, RoleObserver)
+	}
+
+	if learner {
+		roles = append(roles, RoleLearner)
+	}
+
+	return roles
+}
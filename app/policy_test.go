@@ -0,0 +1,33 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolesAllow(t *testing.T) {
+	assert.True(t, rolesAllow([]Role{RoleAdmin}, PermManageUsers))
+	assert.False(t, rolesAllow([]Role{RoleOwner}, PermManageUsers))
+
+	assert.True(t, rolesAllow([]Role{RoleOwner}, PermManageStaff))
+	assert.False(t, rolesAllow([]Role{RoleTeacher}, PermManageStaff))
+
+	assert.True(t, rolesAllow([]Role{RoleTeacher}, PermReview))
+	assert.False(t, rolesAllow([]Role{RoleObserver}, PermReview))
+	assert.True(t, rolesAllow([]Role{RoleObserver}, PermViewProgress))
+
+	assert.False(t, rolesAllow([]Role{RoleLearner}, PermViewProgress))
+	assert.True(t, rolesAllow([]Role{RoleLearner, RoleObserver}, PermViewProgress))
+
+	assert.False(t, rolesAllow(nil, PermViewCourse))
+}
+
+func TestCourseRoles(t *testing.T) {
+	course := Course{OwnerID: "u-1"}
+
+	assert.Equal(t, []Role{RoleOwner}, courseRoles(&User{ID: "u-1"}, course, false, false, false))
+	assert.Equal(t, []Role{RoleAdmin, RoleTeacher}, courseRoles(&User{ID: "u-2", Admin: true}, course, true, false, false))
+	assert.Equal(t, []Role{RoleObserver, RoleLearner}, courseRoles(&User{ID: "u-3"}, course, false, true, true))
+	assert.Empty(t, courseRoles(&User{ID: "u-4"}, course, false, false, false))
+}
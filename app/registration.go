@@ -0,0 +1,94 @@
+package app
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+// checkRegistration checks what someone gives when making their own account.
+func checkRegistration(name, email, password string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: mankas nomo", lib.ErrHTTPBadRequest)
+	}
+
+	if err := checkEmail(email); err != nil {
+		return err
+	}
+
+	return checkNewPassword(password)
+}
+
+// checkEmail makes sure that an email is only a plain address.
+func checkEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("%w: malbona retpoŝto", lib.ErrHTTPBadRequest)
+	}
+
+	return nil
+}
+
+// tokenLink makes a link to the site that carries a token, for an email.
+func tokenLink(publicURL, param, token string) string {
+	return strings.TrimRight(publicURL, "/") + "/?" + url.Values{param: {token}}.Encode()
+}
+
+// verifyMail is the email that asks a new user to confirm their address.
+func verifyMail(publicURL, name, token string) (string, string) {
+	subject := "Konfirmu vian retpoŝton"
+
+	body := fmt.Sprintf(`Saluton %s,
+
+por fini vian registriĝon, iru al:
+
+%s
+
+aŭ uzu ĉi tiun ĵetonon:
+
+%s
+
+Se ne vi registriĝis, simple ignoru ĉi tiun mesaĝon.
+`, name, tokenLink(publicURL, "konfirmi", token), token)
+
+	return subject, body
+}
+
+// registeredMail is sent when someone tries to register with an email that already has an
+// account, so that the response to them can be the same as for a new one.
+func registeredMail(name string) (string, string) {
+	subject := "Vi jam havas konton"
+
+	body := fmt.Sprintf(`Saluton %s,
+
+iu provis registriĝi per ĉi tiu retpoŝto, sed vi jam havas konton. Vi povas simple ensaluti, aŭ
+restarigi vian pasvorton, se vi forgesis ĝin.
+
+Se ne vi provis, simple ignoru ĉi tiun mesaĝon.
+`, name)
+
+	return subject, body
+}
+
+// resetMail is the email that lets a user choose a new password.
+func resetMail(publicURL, name, token string) (string, string) {
+	subject := "Restarigi vian pasvorton"
+
+	body := fmt.Sprintf(`Saluton %s,
+
+por elekti novan pasvorton, iru al:
+
+%s
+
+aŭ uzu ĉi tiun ĵetonon:
+
+%s
+
+Se ne vi petis tion, simple ignoru ĉi tiun mesaĝon. Via pasvorto ne ŝanĝiĝos.
+`, name, tokenLink(publicURL, "restarigi", token), token)
+
+	return subject, body
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+func TestCheckRegistration(t *testing.T) {
+	assert.NoError(t, checkRegistration("Zamenhof", "lz@example.org", "sekreta pasvorto"))
+
+	assert.ErrorIs(t, checkRegistration(" ", "lz@example.org", "sekreta pasvorto"), lib.ErrHTTPBadRequest)
+	assert.ErrorIs(t, checkRegistration("Zamenhof", "lz", "sekreta pasvorto"), lib.ErrHTTPBadRequest)
+	assert.ErrorIs(t, checkRegistration("Zamenhof", "LZ <lz@example.org>", "sekreta pasvorto"), lib.ErrHTTPBadRequest)
+	assert.ErrorIs(t, checkRegistration("Zamenhof", "lz@example.org", "mallon"), lib.ErrHTTPBadRequest)
+}
+
+func TestCheckEmail(t *testing.T) {
+	assert.NoError(t, checkEmail("lz@example.org"))
+
+	assert.ErrorIs(t, checkEmail("lz"), lib.ErrHTTPBadRequest)
+	assert.ErrorIs(t, checkEmail("lz@example.org, alia@example.org"), lib.ErrHTTPBadRequest)
+}
+
+func TestVerifyMail(t *testing.T) {
+	_, body := verifyMail("https://skribi.example/", "Zamenhof", "konfirmi-abc_123")
+
+	assert.Contains(t, body, "Saluton Zamenhof")
+	assert.Contains(t, body, "https://skribi.example/?konfirmi=konfirmi-abc_123")
+}
+
+func TestResetMail(t *testing.T) {
+	_, body := resetMail("https://skribi.example", "Zamenhof", "restarigi-abc_123")
+
+	assert.Contains(t, body, "https://skribi.example/?restarigi=restarigi-abc_123")
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-rel/rel"
+	"github.com/go-rel/rel/where"
+)
+
+// SessionStore is somewhere that an [Authenticator] can keep sessions.
+type SessionStore interface {
+	// PutSession stores a new session.
+	PutSession(ctx context.Context, session Session) error
+	// GetSession finds a session, with its user, or returns [ErrNoSession].
+	GetSession(ctx context.Context, id DBID) (Session, error)
+	// ExtendSession moves the expiry time of a session.
+	ExtendSession(ctx context.Context, id DBID, expires time.Time) error
+	// DeleteSession removes a session, if it exists.
+	DeleteSession(ctx context.Context, id DBID) error
+	// DeleteUserSessions removes all sessions of a user.
+	DeleteUserSessions(ctx context.Context, user DBID) error
+	// DeleteExpiredSessions removes all sessions that expired before a time.
+	DeleteExpiredSessions(ctx context.Context, before time.Time) (int, error)
+}
+
+// MemorySessionStore keeps sessions in a map, so they are lost on restart.
+type MemorySessionStore struct {
+	sync.RWMutex
+	sessions map[DBID]Session
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: map[DBID]Session{},
+	}
+}
+
+func (s *MemorySessionStore) PutSession(_ context.Context, session Session) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.sessions[session.ID] = session
+
+	return nil
+}
+
+func (s *MemorySessionStore) GetSession(_ context.Context, id DBID) (Session, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return Session{}, ErrNoSession
+	}
+
+	return session, nil
+}
+
+func (s *MemorySessionStore) ExtendSession(_ context.Context, id DBID, expires time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrNoSession
+	}
+
+	session.ExpiresAt = expires
+	s.sessions[id] = session
+
+	return nil
+}
+
+func (s *MemorySessionStore) DeleteSession(_ context.Context, id DBID) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.sessions, id)
+
+	return nil
+}
+
+func (s *MemorySessionStore) DeleteUserSessions(_ context.Context, user DBID) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == user {
+			delete(s.sessions, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemorySessionStore) DeleteExpiredSessions(_ context.Context, before time.Time) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	n := 0
+
+	for id, session := range s.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(s.sessions, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// DBSessionStore keeps sessions in the sessions table.
+type DBSessionStore struct {
+	db rel.Repository
+}
+
+func NewDBSessionStore(db rel.Repository) *DBSessionStore {
+	return &DBSessionStore{
+		db: db,
+	}
+}
+
+func (s *DBSessionStore) PutSession(ctx context.Context, session Session) error {
+	if err := s.db.Insert(ctx, &session); err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+func (s *DBSessionStore) GetSession(ctx context.Context, id DBID) (Session, error) {
+	session := &Session{}
+
+	err := s.db.Find(ctx, session, rel.Select("*", "user_x.*").JoinAssoc("user_x"), where.Eq("id", id))
+	if err != nil {
+		if errors.Is(err, rel.ErrNotFound) {
+			return Session{}, ErrNoSession
+		}
+
+		return Session{}, fmt.Errorf("db (read): %w", err)
+	}
+
+	return *session, nil
+}
+
+func (s *DBSessionStore) ExtendSession(ctx context.Context, id DBID, expires time.Time) error {
+	_, err := s.db.UpdateAny(ctx, rel.From("sessions").Where(where.Eq("id", id)), rel.Set("expires_at", expires))
+	if err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+func (s *DBSessionStore) DeleteSession(ctx context.Context, id DBID) error {
+	_, err := s.db.DeleteAny(ctx, rel.From("sessions").Where(where.Eq("id", id)))
+	if err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+func (s *DBSessionStore) DeleteUserSessions(ctx context.Context, user DBID) error {
+	_, err := s.db.DeleteAny(ctx, rel.From("sessions").Where(where.Eq("user", user)))
+	if err != nil {
+		return fmt.Errorf("db (write): %w", err)
+	}
+
+	return nil
+}
+
+func (s *DBSessionStore) DeleteExpiredSessions(ctx context.Context, before time.Time) (int, error) {
+	n, err := s.db.DeleteAny(ctx, rel.From("sessions").Where(where.Lt("expires_at", before)))
+	if err != nil {
+		return 0, fmt.Errorf("db (write): %w", err)
+	}
+
+	return n, nil
+}
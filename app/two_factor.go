@@ -0,0 +1,65 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/undeconstructed/skribserv/lib"
+)
+
+const recoveryCodeCount = 10
+
+// makeRecoveryCodes makes a new set of recovery codes, written in two halves to be easier
+// to read.
+func makeRecoveryCodes() ([]string, error) {
+	out := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		code, err := lib.MakeSecretCode(10)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, code[:5]+"-"+code[5:])
+	}
+
+	return out, nil
+}
+
+// normalRecoveryCode is a recovery code as stored, whatever way the user typed it.
+func normalRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+}
+
+// isTOTPCode says whether a code looks like it came from an app, rather than being a
+// recovery code.
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// withoutUnsafeAdmin takes away admin rights from an admin who has not set up TOTP, if that
+// is required. They can still do everything else, including setting it up.
+func withoutUnsafeAdmin(user *User, require bool) *User {
+	if !require || !user.Admin || user.TOTPEnabledAt != nil {
+		return user
+	}
+
+	out := *user
+	out.Admin = false
+
+	return &out
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := makeRecoveryCodes()
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, codes[0], 11)
+
+	assert.Equal(t, "ABCDE23456", normalRecoveryCode("abcde-23456"))
+	assert.Equal(t, "ABCDE23456", normalRecoveryCode(" ABCDE 23456"))
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	assert.True(t, isTOTPCode("012345"))
+	assert.False(t, isTOTPCode("01234"))
+	assert.False(t, isTOTPCode("ABCDE-23456"))
+}
+
+func TestWithoutUnsafeAdmin(t *testing.T) {
+	now := time.Now()
+
+	admin := &User{ID: "u-1", Admin: true}
+	safe := &User{ID: "u-2", Admin: true, TOTPEnabledAt: &now}
+
+	assert.True(t, withoutUnsafeAdmin(admin, false).Admin)
+	assert.False(t, withoutUnsafeAdmin(admin, true).Admin)
+	assert.True(t, admin.Admin)
+	assert.True(t, withoutUnsafeAdmin(safe, true).Admin)
+}
@@ -13,6 +13,10 @@ type UserJSON struct {
 	Email    string `json:"retpoŝto,omitzero"`
 	Password string `json:"pasvorto,omitzero"`
 	Admin    bool   `json:"admina,omitzero"`
+	// TwoFactor says whether the user logs in with TOTP too.
+	TwoFactor bool `json:"dufaktora,omitzero"`
+	// Locked says whether the user can't log in for now, after too many wrong passwords.
+	Locked bool `json:"ŝlosita,omitzero"`
 }
 
 type CourseJSON struct {
@@ -21,6 +25,10 @@ type CourseJSON struct {
 	Name  string    `json:"nomo,omitzero"`
 	About string    `json:"pri,omitzero"`
 	Time  time.Time `json:"kiamo,omitzero"`
+
+	MaxLearners int `json:"maks_lernantoj,omitzero"`
+
+	Archived bool `json:"arkivita,omitzero"`
 }
 
 type LessonJSON struct {
@@ -28,16 +36,216 @@ type LessonJSON struct {
 	Course CourseJSON `json:"kurso,omitzero"`
 	Name   string     `json:"nomo,omitzero"`
 	Time   time.Time  `json:"kiamo,omitzero"`
+	Order  int        `json:"ordo,omitzero"`
+
+	// Body is the Markdown source, and HTML is what it looks like, ready to show.
+	Body string `json:"enhavo,omitzero"`
+	HTML string `json:"html,omitzero"`
+
+	Archived bool `json:"arkivita,omitzero"`
 }
 
 type LearnerJSON struct {
 	ID     DBID       `json:"id"`
 	Course CourseJSON `json:"kurso,omitzero"`
 	User   UserJSON   `json:"uzanto,omitzero"`
+
+	// Waiting is the place on the waitlist, counting from 1, or 0 if not waiting.
+	Waiting int `json:"atendovico,omitzero"`
+
+	Status      LearnerStatus `json:"stato,omitzero"`
+	EnrolledAt  *time.Time    `json:"aliĝis,omitempty"`
+	PausedAt    *time.Time    `json:"paŭzis,omitempty"`
+	CompletedAt *time.Time    `json:"finis,omitempty"`
+	DroppedAt   *time.Time    `json:"forlasis,omitempty"`
 }
 
 type HomeworkJSON struct {
-	ID      DBID     `json:"id"`
-	Learner UserJSON `json:"lernanto,omitzero"`
-	Text    string   `json:"teksto,omitzero"`
+	ID      DBID       `json:"id"`
+	Learner UserJSON   `json:"lernanto,omitzero"`
+	Lesson  LessonJSON `json:"kursero,omitzero"`
+	Text    string     `json:"teksto,omitzero"`
+
+	Assignment AssignmentJSON `json:"tasko,omitzero"`
+	Late       bool           `json:"malfrua,omitzero"`
+
+	Status      HomeworkStatus `json:"stato,omitzero"`
+	Reviewer    UserJSON       `json:"korektanto,omitzero"`
+	SubmittedAt *time.Time     `json:"ensendita,omitempty"`
+	ClaimedAt   *time.Time     `json:"prenita,omitempty"`
+	CorrectedAt *time.Time     `json:"korektita,omitempty"`
+	ReturnedAt  *time.Time     `json:"redonita,omitempty"`
+
+	Corrections []CorrectionJSON `json:"korektoj,omitempty"`
+}
+
+type AssignmentJSON struct {
+	ID        DBID       `json:"id"`
+	Lesson    LessonJSON `json:"kursero,omitzero"`
+	Prompt    string     `json:"instrukcio,omitzero"`
+	DueAt     *time.Time `json:"limdato,omitempty"`
+	MaxWords  int        `json:"maks_vortoj,omitzero"`
+	AllowLate bool       `json:"malfruo_permesata,omitzero"`
+}
+
+// MyAssignmentsJSON is what a learner has left to do.
+type MyAssignmentsJSON struct {
+	Upcoming []AssignmentJSON `json:"venontaj"`
+	Overdue  []AssignmentJSON `json:"malfruaj"`
+}
+
+type RevisionJSON struct {
+	Number int       `json:"numero"`
+	Text   string    `json:"teksto,omitzero"`
+	Time   time.Time `json:"kiamo,omitzero"`
+}
+
+type DiffJSON struct {
+	From  int            `json:"de"`
+	To    int            `json:"al"`
+	Parts []DiffPartJSON `json:"partoj"`
+}
+
+// DiffPartJSON is some text that is the same ("="), added ("+") or removed ("-").
+type DiffPartJSON struct {
+	Op   string `json:"op"`
+	Text string `json:"teksto"`
+}
+
+type CorrectionJSON struct {
+	ID          DBID             `json:"id"`
+	Revision    int              `json:"versio,omitzero"`
+	Teacher     UserJSON         `json:"instruisto,omitzero"`
+	Text        string           `json:"teksto,omitzero"`
+	Annotations []AnnotationJSON `json:"rimarkoj,omitempty"`
+	Time        time.Time        `json:"kiamo,omitzero"`
+
+	// Segments is the original text, cut up around the annotations, ready to show.
+	Segments []SegmentJSON `json:"eroj,omitempty"`
+}
+
+type AnnotationJSON struct {
+	ID          DBID   `json:"id,omitzero"`
+	Start       int    `json:"komenco"`
+	Length      int    `json:"longo"`
+	Replacement string `json:"anstataŭo,omitzero"`
+	Comment     string `json:"komento,omitzero"`
+	Category    string `json:"kategorio,omitzero"`
+}
+
+// SegmentJSON is a piece of text, which is annotated if Annotation is set.
+type SegmentJSON struct {
+	Text       string          `json:"teksto"`
+	Annotation *AnnotationJSON `json:"rimarko,omitempty"`
+}
+
+type TeacherJSON struct {
+	ID     DBID       `json:"id"`
+	Course CourseJSON `json:"kurso,omitzero"`
+	User   UserJSON   `json:"uzanto,omitzero"`
+}
+
+type ObserverJSON struct {
+	ID     DBID       `json:"id"`
+	Course CourseJSON `json:"kurso,omitzero"`
+	User   UserJSON   `json:"uzanto,omitzero"`
+}
+
+type RubricJSON struct {
+	ID       DBID            `json:"id"`
+	Course   CourseJSON      `json:"kurso,omitzero"`
+	Name     string          `json:"nomo,omitzero"`
+	Criteria []CriterionJSON `json:"kriterioj,omitempty"`
+}
+
+type CriterionJSON struct {
+	ID        DBID   `json:"id,omitzero"`
+	Name      string `json:"nomo,omitzero"`
+	MaxPoints int    `json:"maks_poentoj,omitzero"`
+}
+
+type GradeJSON struct {
+	Criterion CriterionJSON `json:"kriterio"`
+	Points    int           `json:"poentoj"`
+	Comment   string        `json:"komento,omitzero"`
+}
+
+// HomeworkGradesJSON is all the grades of one homework, with totals.
+type HomeworkGradesJSON struct {
+	Homework  DBID        `json:"hejmtasko"`
+	Rubric    DBID        `json:"rubriko,omitzero"`
+	Grades    []GradeJSON `json:"notoj"`
+	Total     int         `json:"sumo"`
+	MaxPoints int         `json:"maksimumo"`
+}
+
+type GradebookJSON struct {
+	Assignments []AssignmentJSON   `json:"taskoj"`
+	Learners    []GradebookRowJSON `json:"lernantoj"`
+}
+
+// GradebookRowJSON has a score for each assignment, in the same order, or null if not graded.
+type GradebookRowJSON struct {
+	Learner UserJSON     `json:"lernanto"`
+	Scores  []*ScoreJSON `json:"poentoj"`
+}
+
+type ScoreJSON struct {
+	Homework  DBID `json:"hejmtasko"`
+	Total     int  `json:"sumo"`
+	MaxPoints int  `json:"maksimumo"`
+}
+
+type CommentJSON struct {
+	ID       DBID     `json:"id"`
+	Author   UserJSON `json:"aŭtoro,omitzero"`
+	ParentID *DBID    `json:"respondo_al,omitempty"`
+	Text     string   `json:"teksto,omitzero"`
+
+	Revision int  `json:"versio,omitzero"`
+	Start    *int `json:"komenco,omitempty"`
+	Length   *int `json:"longo,omitempty"`
+	// Quote is the part of the text that the comment is about.
+	Quote string `json:"citaĵo,omitzero"`
+
+	Created time.Time  `json:"kreita,omitzero"`
+	Edited  *time.Time `json:"redaktita,omitempty"`
+	Removed bool       `json:"forigita,omitzero"`
+
+	Replies []CommentJSON `json:"respondoj,omitempty"`
+}
+
+type AttachmentJSON struct {
+	ID       DBID      `json:"id"`
+	Name     string    `json:"nomo,omitzero"`
+	MimeType string    `json:"tipo,omitzero"`
+	Size     int64     `json:"grandeco,omitzero"`
+	Uploader UserJSON  `json:"alŝutinto,omitzero"`
+	Time     time.Time `json:"kiamo,omitzero"`
+}
+
+type APITokenJSON struct {
+	ID     DBID     `json:"id"`
+	Name   string   `json:"nomo,omitzero"`
+	Scopes []string `json:"rajtoj,omitempty"`
+	// Token is only there when it is new.
+	Token      string     `json:"ĵetono,omitzero"`
+	CreatedAt  time.Time  `json:"kreita,omitzero"`
+	ExpiresAt  *time.Time `json:"limdato,omitempty"`
+	LastUsedAt *time.Time `json:"uzita,omitempty"`
+}
+
+// TwoFactorJSON is what an app needs to start making TOTP codes, and the recovery codes once
+// it does.
+type TwoFactorJSON struct {
+	Secret        string   `json:"sekreto,omitzero"`
+	URI           string   `json:"uri,omitzero"`
+	RecoveryCodes []string `json:"rezervaj_kodoj,omitempty"`
+}
+
+type JoinCodeJSON struct {
+	Code      string     `json:"kodo,omitzero"`
+	ExpiresAt *time.Time `json:"limdato,omitempty"`
+	MaxUses   int        `json:"maks_uzoj,omitzero"`
+	Uses      int        `json:"uzoj"`
 }
@@ -12,8 +12,25 @@ type User struct {
 
 	Admin bool
 
+	// VerifiedAt is when the user confirmed their email. Until then, they cannot log in.
+	VerifiedAt *time.Time
+
+	// TOTPSecret is only used once TOTPEnabledAt is set, after the user has shown that their
+	// app has it.
+	TOTPSecret    string     `db:"totp_secret"`
+	TOTPEnabledAt *time.Time `db:"totp_enabled_at"`
+	// TOTPLastStep is the time step of the last code accepted, so that no code works twice.
+	TOTPLastStep int64 `db:"totp_last_step"`
+
+	// FailedLogins counts wrong passwords in a row, until there are enough to lock the account
+	// until LockedUntil.
+	FailedLogins int
+	LockedUntil  *time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt makes rel soft delete, and hide deleted users from normal queries.
+	DeletedAt *time.Time
 }
 
 func (User) Table() string {
@@ -28,6 +45,18 @@ type Course struct {
 	About   string
 	Time    time.Time
 
+	ArchivedAt *time.Time
+
+	// MaxLearners is how many learners there can be, not counting the waitlist, or 0 for no limit.
+	MaxLearners int
+
+	// JoinCode lets users join the course themselves. It is empty when that is not allowed.
+	JoinCode      string
+	JoinExpiresAt *time.Time
+	// JoinMaxUses is how many times the code can be used, or 0 for no limit.
+	JoinMaxUses int
+	JoinUses    int
+
 	Lessons []Lesson `ref:"id" fk:"course"`
 }
 
@@ -36,10 +65,15 @@ func (Course) Table() string {
 }
 
 type Lesson struct {
-	ID     DBID
-	Course DBID
-	Name   string
-	Time   time.Time
+	ID       DBID
+	Course   DBID
+	Name     string
+	Time     time.Time
+	Position int
+	// Body is the content of the lesson, in Markdown.
+	Body string
+
+	ArchivedAt *time.Time
 }
 
 func (Lesson) Table() string {
@@ -52,6 +86,17 @@ type Learner struct {
 	UserX    User   `ref:"user" fk:"id"`
 	CourseID DBID   `db:"course"`
 	CourseX  Course `ref:"course" fk:"id"`
+
+	// WaitingSince is set while the learner is on the waitlist, because the course is full.
+	WaitingSince *time.Time
+
+	Status      LearnerStatus
+	EnrolledAt  *time.Time
+	PausedAt    *time.Time
+	CompletedAt *time.Time
+	DroppedAt   *time.Time
+	// DroppedByID is whoever took the learner out of the course, the learner or someone else.
+	DroppedByID *DBID `db:"dropped_by"`
 }
 
 func (Learner) Table() string {
@@ -69,14 +114,287 @@ func (Teacher) Table() string {
 	return "teachers"
 }
 
+// Observer can see how a course is going, without being able to change anything.
+type Observer struct {
+	ID       DBID
+	UserID   DBID `db:"user"`
+	UserX    User `ref:"user" fk:"id"`
+	CourseID DBID `db:"course"`
+}
+
+func (Observer) Table() string {
+	return "observers"
+}
+
 type Homework struct {
 	ID        DBID
-	LearnerID DBID   `db:"learner"`
-	LessonID  DBID   `db:"lesson"`
-	LessonX   Lesson `ref:"lesson" fk:"id"`
-	Text      string
+	LearnerID DBID    `db:"learner"`
+	LearnerX  Learner `ref:"learner" fk:"id"`
+	LessonID  DBID    `db:"lesson"`
+	LessonX   Lesson  `ref:"lesson" fk:"id"`
+	Text      string  `db:"teksto"`
+
+	AssignmentID *DBID `db:"assignment"`
+	Late         bool
+
+	Status     HomeworkStatus
+	ReviewerID *DBID `db:"reviewer"`
+
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	SubmittedAt *time.Time
+	ClaimedAt   *time.Time
+	CorrectedAt *time.Time
+	ReturnedAt  *time.Time
 }
 
 func (Homework) Table() string {
 	return "homeworks"
 }
+
+type Session struct {
+	ID        DBID
+	UserID    DBID `db:"user"`
+	UserX     User `ref:"user" fk:"id"`
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// Pending sessions only allow giving a second factor.
+	Pending bool
+}
+
+func (Session) Table() string {
+	return "sessions"
+}
+
+// APIToken lets a script act as a user, with only some of their rights.
+type APIToken struct {
+	ID     DBID
+	UserID DBID `db:"user"`
+	UserX  User `ref:"user" fk:"id"`
+	Name   string
+	// Hash is of the token itself, which is only shown once.
+	Hash string
+	// Scopes are separated by commas.
+	Scopes string
+
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func (APIToken) Table() string {
+	return "api_tokens"
+}
+
+// RecoveryCode lets a user in without their TOTP app, once. The ID is a hash of the code.
+type RecoveryCode struct {
+	ID        DBID
+	UserID    DBID `db:"user"`
+	CreatedAt time.Time
+}
+
+func (RecoveryCode) Table() string {
+	return "recovery_codes"
+}
+
+// TokenPurpose says what a [UserToken] can be used for.
+type TokenPurpose string
+
+const (
+	TokenVerify TokenPurpose = "konfirmi"
+	TokenReset  TokenPurpose = "restarigi"
+)
+
+// UserToken is a single use secret that is emailed to a user. As with sessions, the ID is a
+// hash of the token.
+type UserToken struct {
+	ID        DBID
+	UserID    DBID `db:"user"`
+	Purpose   TokenPurpose
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (UserToken) Table() string {
+	return "user_tokens"
+}
+
+// OIDCLogin is a login that was sent to the OIDC provider, and has not come back yet. The ID
+// is a hash of the state, which also goes to the browser in a cookie.
+type OIDCLogin struct {
+	ID        DBID
+	Nonce     string
+	Verifier  string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (OIDCLogin) Table() string {
+	return "oidc_logins"
+}
+
+// Assignment is some writing that learners are asked to do for a lesson.
+type Assignment struct {
+	ID       DBID
+	LessonID DBID   `db:"lesson"`
+	LessonX  Lesson `ref:"lesson" fk:"id"`
+	Prompt   string
+	DueAt    *time.Time
+	// MaxWords is 0 for no limit.
+	MaxWords  int
+	AllowLate bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Assignment) Table() string {
+	return "assignments"
+}
+
+// HomeworkRevision is a copy of homework text, as it was each time it was submitted.
+type HomeworkRevision struct {
+	ID         DBID
+	HomeworkID DBID `db:"homework"`
+	Number     int
+	Text       string
+
+	CreatedAt time.Time
+}
+
+func (HomeworkRevision) Table() string {
+	return "homework_revisions"
+}
+
+type Correction struct {
+	ID         DBID
+	HomeworkID DBID `db:"homework"`
+	TeacherID  DBID `db:"teacher"`
+	TeacherX   User `ref:"teacher" fk:"id"`
+	Text       string
+	// Revision is the number of the revision that was corrected, or 0 from before revisions.
+	Revision int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Annotations []Annotation `ref:"id" fk:"correction"`
+}
+
+func (Correction) Table() string {
+	return "corrections"
+}
+
+// Annotation marks a span of the original homework text, counted in runes.
+type Annotation struct {
+	ID           DBID
+	CorrectionID DBID `db:"correction"`
+	Start        int
+	Length       int
+	Replacement  string
+	Comment      string
+	Category     string
+}
+
+func (Annotation) Table() string {
+	return "annotations"
+}
+
+type Rubric struct {
+	ID       DBID
+	CourseID DBID `db:"course"`
+	Name     string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Criteria []RubricCriterion `ref:"id" fk:"rubric"`
+}
+
+func (Rubric) Table() string {
+	return "rubrics"
+}
+
+type RubricCriterion struct {
+	ID        DBID
+	RubricID  DBID `db:"rubric"`
+	Name      string
+	MaxPoints int
+	Position  int
+}
+
+func (RubricCriterion) Table() string {
+	return "rubric_criteria"
+}
+
+// Grade is the points given to some homework for one criterion of a rubric.
+type Grade struct {
+	ID          DBID
+	HomeworkID  DBID            `db:"homework"`
+	CriterionID DBID            `db:"criterion"`
+	CriterionX  RubricCriterion `ref:"criterion" fk:"id"`
+	TeacherID   DBID            `db:"teacher"`
+	Points      int
+	Comment     string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Grade) Table() string {
+	return "grades"
+}
+
+// Comment is part of a conversation about some homework. It can be a reply to another
+// comment, and can be about some part of the text.
+type Comment struct {
+	ID         DBID
+	HomeworkID DBID  `db:"homework"`
+	AuthorID   DBID  `db:"author"`
+	AuthorX    User  `ref:"author" fk:"id"`
+	ParentID   *DBID `db:"parent"`
+	Text       string
+
+	// Revision is the number of the revision that the comment was made on.
+	Revision int
+	Start    *int
+	Length   *int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// RemovedAt is set when the author takes the comment back. It is kept so that replies
+	// still make sense.
+	RemovedAt *time.Time
+}
+
+func (Comment) Table() string {
+	return "comments"
+}
+
+// Attachment is a file belonging to either a lesson or some homework. The content is kept
+// in the blob store, under [Attachment.BlobKey].
+type Attachment struct {
+	ID         DBID
+	LessonID   *DBID `db:"lesson"`
+	HomeworkID *DBID `db:"homework"`
+	UploaderID DBID  `db:"uploader"`
+	UploaderX  User  `ref:"uploader" fk:"id"`
+
+	Name     string
+	MimeType string
+	Size     int64
+
+	CreatedAt time.Time
+}
+
+func (Attachment) Table() string {
+	return "attachments"
+}
+
+func (a Attachment) BlobKey() string {
+	if a.LessonID != nil {
+		return "kurseroj/" + string(*a.LessonID) + "/" + string(a.ID)
+	}
+
+	return "hejmtaskoj/" + string(*a.HomeworkID) + "/" + string(a.ID)
+}
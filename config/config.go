@@ -3,6 +3,8 @@ package config
 import (
 	"errors"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -10,6 +12,99 @@ import (
 type Config struct {
 	DBDSN      string `yaml:"dbdsn"`
 	ListenAddr string `yaml:"listen_addr"`
+
+	// SessionTTL is how long a session lasts without being used.
+	SessionTTL time.Duration `yaml:"session_ttl"`
+
+	// ResetTTL is how long an emailed password reset token lasts.
+	ResetTTL time.Duration `yaml:"reset_ttl"`
+
+	Cookie CookieConfig `yaml:"cookie"`
+
+	Files FilesConfig `yaml:"files"`
+
+	// PublicURL is where people find the site, for links in emails.
+	PublicURL string `yaml:"public_url"`
+
+	Mail MailConfig `yaml:"mail"`
+
+	Registration RegistrationConfig `yaml:"registration"`
+
+	TwoFactor TwoFactorConfig `yaml:"two_factor"`
+
+	OIDC OIDCConfig `yaml:"oidc"`
+
+	LoginLimits LoginLimitsConfig `yaml:"login_limits"`
+}
+
+// CookieConfig sets attributes of the session cookie. It is always HttpOnly.
+type CookieConfig struct {
+	// Secure should be true whenever the server is behind HTTPS.
+	Secure bool `yaml:"secure"`
+	// SameSite is one of "strict", "lax" or "none".
+	SameSite string `yaml:"same_site"`
+	Domain   string `yaml:"domain"`
+}
+
+// FilesConfig says where uploaded files are kept, and what may be uploaded.
+type FilesConfig struct {
+	Dir string `yaml:"dir"`
+	// MaxSize is the biggest file allowed, in bytes.
+	MaxSize int64 `yaml:"max_size"`
+	// Types are the allowed MIME types, as sniffed from the content.
+	Types []string `yaml:"types"`
+}
+
+// MailConfig says how to send emails. Without an address, emails are only logged.
+type MailConfig struct {
+	// Addr is the host:port of an SMTP server.
+	Addr     string `yaml:"addr"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// RegistrationConfig says whether people can make their own accounts. They must confirm their
+// email before they can log in.
+type RegistrationConfig struct {
+	Open bool `yaml:"open"`
+	// VerifyTTL is how long the emailed token lasts.
+	VerifyTTL time.Duration `yaml:"verify_ttl"`
+}
+
+// TwoFactorConfig is about logging in with TOTP as well as a password.
+type TwoFactorConfig struct {
+	// Issuer is the name that authenticator apps show.
+	Issuer string `yaml:"issuer"`
+	// RequireAdmins takes away admin rights from admins who have not set up TOTP, until they do.
+	RequireAdmins bool `yaml:"require_admins"`
+}
+
+// OIDCConfig is for logging in at an OpenID Connect provider, as well as with a password.
+// Without an issuer, it is off.
+type OIDCConfig struct {
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL is where the provider sends users back to, which must be registered there.
+	RedirectURL string `yaml:"redirect_url"`
+	// Provision makes accounts for people who log in at the provider, but have none here yet.
+	// Otherwise, only people with an account of the same email can log in.
+	Provision bool `yaml:"provision"`
+}
+
+// LoginLimitsConfig is about slowing down people who guess passwords, both from one address
+// and at one account.
+type LoginLimitsConfig struct {
+	// FreeFailures are how many failures there can be before any waiting.
+	FreeFailures int `yaml:"free_failures"`
+	// BaseDelay is the first wait, which doubles with each failure after, up to MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay"`
+	MaxDelay  time.Duration `yaml:"max_delay"`
+	// LockoutFailures locks an account after so many failures in a row, for LockoutTime, or
+	// until an admin unlocks it.
+	LockoutFailures int           `yaml:"lockout_failures"`
+	LockoutTime     time.Duration `yaml:"lockout_time"`
 }
 
 func ReadConfig(paths ...string) (*Config, string, error) {
@@ -40,4 +135,76 @@ func fillConfig(config *Config, _ string) {
 	if config.ListenAddr == "" {
 		config.ListenAddr = ":8080"
 	}
+
+	if config.SessionTTL == 0 {
+		config.SessionTTL = 24 * time.Hour
+	}
+
+	if config.ResetTTL == 0 {
+		config.ResetTTL = time.Hour
+	}
+
+	if config.Cookie.SameSite == "" {
+		config.Cookie.SameSite = "lax"
+	}
+
+	if config.PublicURL == "" {
+		config.PublicURL = "http://" + config.ListenAddr
+	}
+
+	if config.Mail.From == "" {
+		config.Mail.From = "skribserv@localhost"
+	}
+
+	if config.Registration.VerifyTTL == 0 {
+		config.Registration.VerifyTTL = 48 * time.Hour
+	}
+
+	if config.TwoFactor.Issuer == "" {
+		config.TwoFactor.Issuer = "Skribserv"
+	}
+
+	if config.OIDC.RedirectURL == "" {
+		config.OIDC.RedirectURL = strings.TrimRight(config.PublicURL, "/") + "/api/mi/ensaluti/oidc/reveno"
+	}
+
+	if config.LoginLimits.FreeFailures == 0 {
+		config.LoginLimits.FreeFailures = 3
+	}
+
+	if config.LoginLimits.BaseDelay == 0 {
+		config.LoginLimits.BaseDelay = time.Second
+	}
+
+	if config.LoginLimits.MaxDelay == 0 {
+		config.LoginLimits.MaxDelay = 15 * time.Minute
+	}
+
+	if config.LoginLimits.LockoutFailures == 0 {
+		config.LoginLimits.LockoutFailures = 10
+	}
+
+	if config.LoginLimits.LockoutTime == 0 {
+		config.LoginLimits.LockoutTime = time.Hour
+	}
+
+	if config.Files.Dir == "" {
+		config.Files.Dir = "files"
+	}
+
+	if config.Files.MaxSize == 0 {
+		config.Files.MaxSize = 10 << 20
+	}
+
+	if len(config.Files.Types) == 0 {
+		config.Files.Types = []string{
+			"application/pdf",
+			"image/jpeg",
+			"image/png",
+			"audio/mpeg",
+			"audio/wave",
+			"application/ogg",
+			"text/plain",
+		}
+	}
 }
@@ -47,6 +47,28 @@ func Setup(dbdsn string, log *slog.Logger) (rel.Repository, error) {
 	m := migration.New(db)
 
 	m.Register(2025010101000000, migrations.MigrateCreateInitial, migrations.RollbackCreateInitial)
+	m.Register(2025020101000000, migrations.MigrateCreateSessions, migrations.RollbackCreateSessions)
+	m.Register(2025030101000000, migrations.MigrateUsersDeleted, migrations.RollbackUsersDeleted)
+	m.Register(2025040101000000, migrations.MigrateCoursesArchive, migrations.RollbackCoursesArchive)
+	m.Register(2025050101000000, migrations.MigrateTeachersUnique, migrations.RollbackTeachersUnique)
+	m.Register(2025060101000000, migrations.MigrateCreateCorrections, migrations.RollbackCreateCorrections)
+	m.Register(2025070101000000, migrations.MigrateHomeworkStatus, migrations.RollbackHomeworkStatus)
+	m.Register(2025080101000000, migrations.MigrateCreateHomeworkRevisions, migrations.RollbackCreateHomeworkRevisions)
+	m.Register(2025090101000000, migrations.MigrateCreateAssignments, migrations.RollbackCreateAssignments)
+	m.Register(2025100101000000, migrations.MigrateCreateRubrics, migrations.RollbackCreateRubrics)
+	m.Register(2025110101000000, migrations.MigrateCreateComments, migrations.RollbackCreateComments)
+	m.Register(2025120101000000, migrations.MigrateLessonsBody, migrations.RollbackLessonsBody)
+	m.Register(2026010101000000, migrations.MigrateCreateAttachments, migrations.RollbackCreateAttachments)
+	m.Register(2026020101000000, migrations.MigrateJoinCodes, migrations.RollbackJoinCodes)
+	m.Register(2026030101000000, migrations.MigrateCapacity, migrations.RollbackCapacity)
+	m.Register(2026040101000000, migrations.MigrateLearnerStates, migrations.RollbackLearnerStates)
+	m.Register(2026050101000000, migrations.MigrateRegistration, migrations.RollbackRegistration)
+	m.Register(2026060101000000, migrations.MigrateObservers, migrations.RollbackObservers)
+	m.Register(2026070101000000, migrations.MigrateAPITokens, migrations.RollbackAPITokens)
+	m.Register(2026080101000000, migrations.MigrateTwoFactor, migrations.RollbackTwoFactor)
+	m.Register(2026090101000000, migrations.MigrateOIDC, migrations.RollbackOIDC)
+	m.Register(2026100101000000, migrations.MigrateLoginLockout, migrations.RollbackLoginLockout)
+	m.Register(2026110101000000, migrations.MigrateLearnerDroppedBy, migrations.RollbackLearnerDroppedBy)
 
 	m.Migrate(context.Background())
 
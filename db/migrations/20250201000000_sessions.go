@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCreateSessions(schema *rel.Schema) {
+	// sessions: ensalutoj, kiuj restas post restarto
+	schema.CreateTable("sessions", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("user", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("expires_at", rel.Required(true))
+
+		t.ForeignKey("user", "users", "id", rel.OnDelete("cascade"))
+	})
+
+	schema.CreateIndex("sessions", "sessions_expires_at", []string{"expires_at"})
+}
+
+func RollbackCreateSessions(schema *rel.Schema) {
+	schema.DropTable("sessions")
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateUsersDeleted(schema *rel.Schema) {
+	// users.deleted_at: por malaktivigi uzanton sen perdi ĝiajn datumojn
+	schema.AddColumn("users", "deleted_at", rel.DateTime)
+}
+
+func RollbackUsersDeleted(schema *rel.Schema) {
+	schema.DropColumn("users", "deleted_at")
+}
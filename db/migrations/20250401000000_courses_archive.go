@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCoursesArchive(schema *rel.Schema) {
+	// courses.archived_at: finitaj kursoj, kiuj ne plu montriĝas
+	schema.AddColumn("courses", "archived_at", rel.DateTime)
+
+	// lessons.position: por ke instruistoj ordigu lecionojn
+	schema.AddColumn("lessons", "position", rel.Int, rel.Required(true), rel.Default(0))
+	schema.AddColumn("lessons", "archived_at", rel.DateTime)
+}
+
+func RollbackCoursesArchive(schema *rel.Schema) {
+	schema.DropColumn("lessons", "archived_at")
+	schema.DropColumn("lessons", "position")
+
+	schema.DropColumn("courses", "archived_at")
+}
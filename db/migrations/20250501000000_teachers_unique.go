@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateTeachersUnique(schema *rel.Schema) {
+	// teachers: ĉiu uzanto instruas ĉiun kurson maksimume unufoje
+	schema.CreateUniqueIndex("teachers", "teachers_user_course", []string{"user", "course"})
+}
+
+func RollbackTeachersUnique(schema *rel.Schema) {
+	schema.DropIndex("teachers", "teachers_user_course")
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCreateCorrections(schema *rel.Schema) {
+	// corrections: korektita versio de hejmtasko, de instruisto
+	schema.CreateTable("corrections", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("homework", rel.Required(true))
+		t.String("teacher", rel.Required(true))
+
+		t.Text("text", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("updated_at", rel.Required(true))
+
+		t.ForeignKey("homework", "homeworks", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("teacher", "users", "id", rel.OnDelete("cascade"))
+	})
+
+	// annotations: rimarkoj pri unuopaj partoj de la teksto
+	schema.CreateTable("annotations", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("correction", rel.Required(true))
+
+		t.Int("start", rel.Required(true))
+		t.Int("length", rel.Required(true))
+		t.Text("replacement", rel.Required(true))
+		t.Text("comment", rel.Required(true))
+		t.String("category", rel.Required(true))
+
+		t.ForeignKey("correction", "corrections", "id", rel.OnDelete("cascade"))
+	})
+}
+
+func RollbackCreateCorrections(schema *rel.Schema) {
+	schema.DropTable("annotations")
+
+	schema.DropTable("corrections")
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateHomeworkStatus(schema *rel.Schema) {
+	// homeworks.status: kie en la korektado troviĝas la hejmtasko
+	schema.AddColumn("homeworks", "status", rel.String, rel.Required(true), rel.Default("ensendita"))
+	schema.AddColumn("homeworks", "reviewer", rel.String)
+
+	schema.AddColumn("homeworks", "created_at", rel.DateTime)
+	schema.AddColumn("homeworks", "updated_at", rel.DateTime)
+	schema.AddColumn("homeworks", "submitted_at", rel.DateTime)
+	schema.AddColumn("homeworks", "claimed_at", rel.DateTime)
+	schema.AddColumn("homeworks", "corrected_at", rel.DateTime)
+	schema.AddColumn("homeworks", "returned_at", rel.DateTime)
+
+	schema.CreateIndex("homeworks", "homeworks_status", []string{"status"})
+}
+
+func RollbackHomeworkStatus(schema *rel.Schema) {
+	schema.DropIndex("homeworks", "homeworks_status")
+
+	schema.DropColumn("homeworks", "returned_at")
+	schema.DropColumn("homeworks", "corrected_at")
+	schema.DropColumn("homeworks", "claimed_at")
+	schema.DropColumn("homeworks", "submitted_at")
+	schema.DropColumn("homeworks", "updated_at")
+	schema.DropColumn("homeworks", "created_at")
+
+	schema.DropColumn("homeworks", "reviewer")
+	schema.DropColumn("homeworks", "status")
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCreateHomeworkRevisions(schema *rel.Schema) {
+	// homework_revisions: ĉiu ensendita versio de hejmtasko
+	schema.CreateTable("homework_revisions", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("homework", rel.Required(true))
+		t.Int("number", rel.Required(true))
+
+		t.Text("text", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+
+		t.Unique([]string{"homework", "number"})
+
+		t.ForeignKey("homework", "homeworks", "id", rel.OnDelete("cascade"))
+	})
+
+	// corrections.revision: la versio, al kiu rilatas la rimarkoj
+	schema.AddColumn("corrections", "revision", rel.Int, rel.Required(true), rel.Default(0))
+}
+
+func RollbackCreateHomeworkRevisions(schema *rel.Schema) {
+	schema.DropColumn("corrections", "revision")
+
+	schema.DropTable("homework_revisions")
+}
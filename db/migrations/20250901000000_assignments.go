@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCreateAssignments(schema *rel.Schema) {
+	// assignments: kion lernantoj devas skribi por kursero, kaj ĝis kiam
+	schema.CreateTable("assignments", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("lesson", rel.Required(true))
+
+		t.Text("prompt", rel.Required(true))
+		t.DateTime("due_at")
+		t.Int("max_words", rel.Required(true), rel.Default(0))
+		t.Bool("allow_late", rel.Required(true), rel.Default(false))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("updated_at", rel.Required(true))
+
+		t.ForeignKey("lesson", "lessons", "id", rel.OnDelete("cascade"))
+	})
+
+	// homeworks.assignment: la tasko, al kiu respondas la hejmtasko
+	schema.AddColumn("homeworks", "assignment", rel.String)
+	schema.AddColumn("homeworks", "late", rel.Bool, rel.Required(true), rel.Default(false))
+
+	schema.AlterTable("homeworks", func(t *rel.AlterTable) {
+		t.ForeignKey("assignment", "assignments", "id", rel.OnDelete("set null"))
+	})
+}
+
+func RollbackCreateAssignments(schema *rel.Schema) {
+	schema.DropColumn("homeworks", "late")
+	schema.DropColumn("homeworks", "assignment")
+
+	schema.DropTable("assignments")
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCreateRubrics(schema *rel.Schema) {
+	// rubrics: kiel instruistoj de kurso taksas hejmtaskojn
+	schema.CreateTable("rubrics", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("course", rel.Required(true))
+		t.String("name", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("updated_at", rel.Required(true))
+
+		t.ForeignKey("course", "courses", "id", rel.OnDelete("cascade"))
+	})
+
+	// rubric_criteria: unuopaj kriterioj de rubriko, ĉiu kun sia skalo
+	schema.CreateTable("rubric_criteria", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("rubric", rel.Required(true))
+		t.String("name", rel.Required(true))
+		t.Int("max_points", rel.Required(true))
+		t.Int("position", rel.Required(true))
+
+		t.ForeignKey("rubric", "rubrics", "id", rel.OnDelete("cascade"))
+	})
+
+	// grades: poentoj por unu kriterio de unu hejmtasko
+	schema.CreateTable("grades", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("homework", rel.Required(true))
+		t.String("criterion", rel.Required(true))
+		t.String("teacher", rel.Required(true))
+
+		t.Int("points", rel.Required(true))
+		t.Text("comment", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("updated_at", rel.Required(true))
+
+		t.Unique([]string{"homework", "criterion"})
+
+		t.ForeignKey("homework", "homeworks", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("criterion", "rubric_criteria", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("teacher", "users", "id", rel.OnDelete("cascade"))
+	})
+}
+
+func RollbackCreateRubrics(schema *rel.Schema) {
+	schema.DropTable("grades")
+
+	schema.DropTable("rubric_criteria")
+
+	schema.DropTable("rubrics")
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCreateComments(schema *rel.Schema) {
+	// comments: interparolo pri hejmtasko inter lernanto kaj instruistoj
+	schema.CreateTable("comments", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("homework", rel.Required(true))
+		t.String("author", rel.Required(true))
+		t.String("parent")
+
+		t.Text("text", rel.Required(true))
+
+		// la komento povas rilati al parto de iu versio de la teksto
+		t.Int("revision", rel.Required(true))
+		t.Int("start")
+		t.Int("length")
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("updated_at", rel.Required(true))
+		t.DateTime("removed_at")
+
+		t.ForeignKey("homework", "homeworks", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("author", "users", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("parent", "comments", "id", rel.OnDelete("cascade"))
+	})
+}
+
+func RollbackCreateComments(schema *rel.Schema) {
+	schema.DropTable("comments")
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateLessonsBody(schema *rel.Schema) {
+	// lessons.body: enhavo de la leciono, en Markdown
+	schema.AddColumn("lessons", "body", rel.Text, rel.Required(true), rel.Default(""))
+}
+
+func RollbackLessonsBody(schema *rel.Schema) {
+	schema.DropColumn("lessons", "body")
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCreateAttachments(schema *rel.Schema) {
+	// attachments: dosieroj de kursero aŭ de hejmtasko; la enhavo mem estas aliloke
+	schema.CreateTable("attachments", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("lesson")
+		t.String("homework")
+		t.String("uploader", rel.Required(true))
+
+		t.String("name", rel.Required(true))
+		t.String("mime_type", rel.Required(true))
+		t.BigInt("size", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+
+		t.ForeignKey("lesson", "lessons", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("homework", "homeworks", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("uploader", "users", "id", rel.OnDelete("cascade"))
+	})
+}
+
+func RollbackCreateAttachments(schema *rel.Schema) {
+	schema.DropTable("attachments")
+}
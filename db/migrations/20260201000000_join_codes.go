@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateJoinCodes(schema *rel.Schema) {
+	// courses.join_*: kodo, per kiu uzantoj mem aliĝas al kurso
+	schema.AddColumn("courses", "join_code", rel.String, rel.Required(true), rel.Default(""))
+	schema.AddColumn("courses", "join_expires_at", rel.DateTime)
+	schema.AddColumn("courses", "join_max_uses", rel.Int, rel.Required(true), rel.Default(0))
+	schema.AddColumn("courses", "join_uses", rel.Int, rel.Required(true), rel.Default(0))
+}
+
+func RollbackJoinCodes(schema *rel.Schema) {
+	schema.DropColumn("courses", "join_uses")
+	schema.DropColumn("courses", "join_max_uses")
+	schema.DropColumn("courses", "join_expires_at")
+	schema.DropColumn("courses", "join_code")
+}
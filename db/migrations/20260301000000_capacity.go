@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateCapacity(schema *rel.Schema) {
+	// courses.max_learners: kiom da lernantoj povas esti en la kurso, aŭ 0 por senlime
+	schema.AddColumn("courses", "max_learners", rel.Int, rel.Required(true), rel.Default(0))
+
+	// learners.waiting_since: lernantoj, kiuj atendas lokon, laŭ ordo de alveno
+	schema.AddColumn("learners", "waiting_since", rel.DateTime)
+}
+
+func RollbackCapacity(schema *rel.Schema) {
+	schema.DropColumn("learners", "waiting_since")
+
+	schema.DropColumn("courses", "max_learners")
+}
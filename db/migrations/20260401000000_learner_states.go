@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateLearnerStates(schema *rel.Schema) {
+	// learners.status: ĉu la lernanto aktivas, paŭzas, finis aŭ forlasis la kurson
+	schema.AddColumn("learners", "status", rel.String, rel.Required(true), rel.Default("aktiva"))
+
+	schema.AddColumn("learners", "enrolled_at", rel.DateTime)
+	schema.AddColumn("learners", "paused_at", rel.DateTime)
+	schema.AddColumn("learners", "completed_at", rel.DateTime)
+	schema.AddColumn("learners", "dropped_at", rel.DateTime)
+}
+
+func RollbackLearnerStates(schema *rel.Schema) {
+	schema.DropColumn("learners", "dropped_at")
+	schema.DropColumn("learners", "completed_at")
+	schema.DropColumn("learners", "paused_at")
+	schema.DropColumn("learners", "enrolled_at")
+
+	schema.DropColumn("learners", "status")
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateRegistration(schema *rel.Schema) {
+	// users.verified_at: kiam la uzanto konfirmis sian retpoŝton; ĝis tiam ne eblas ensaluti
+	schema.AddColumn("users", "verified_at", rel.DateTime)
+
+	// uzantoj jam ekzistantaj estis faritaj de adminoj, do fidindaj
+	schema.Exec(rel.Raw("UPDATE users SET verified_at = created_at"))
+
+	// user_tokens: unufojaj ĵetonoj senditaj al uzantoj, ekz. por konfirmi retpoŝton
+	schema.CreateTable("user_tokens", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("user", rel.Required(true))
+		t.String("purpose", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("expires_at", rel.Required(true))
+
+		t.ForeignKey("user", "users", "id", rel.OnDelete("cascade"))
+	})
+
+	schema.CreateIndex("user_tokens", "user_tokens_user", []string{"user"})
+}
+
+func RollbackRegistration(schema *rel.Schema) {
+	schema.DropTable("user_tokens")
+
+	schema.DropColumn("users", "verified_at")
+}
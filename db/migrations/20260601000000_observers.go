@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateObservers(schema *rel.Schema) {
+	// observers: uzantoj, kiuj rajtas vidi la progreson de kurso, sed nenion ŝanĝi
+	schema.CreateTable("observers", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("user", rel.Required(true))
+		t.String("course", rel.Required(true))
+
+		t.ForeignKey("user", "users", "id", rel.OnDelete("cascade"))
+		t.ForeignKey("course", "courses", "id", rel.OnDelete("cascade"))
+	})
+
+	schema.CreateUniqueIndex("observers", "observers_user_course", []string{"user", "course"})
+}
+
+func RollbackObservers(schema *rel.Schema) {
+	schema.DropTable("observers")
+}
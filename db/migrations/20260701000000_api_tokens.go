@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateAPITokens(schema *rel.Schema) {
+	// api_tokens: ĵetonoj por skriptoj, anstataŭ pasvorto; nur haŝo de ĉiu estas konservita
+	schema.CreateTable("api_tokens", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("user", rel.Required(true))
+		t.String("name", rel.Required(true))
+		t.String("hash", rel.Required(true), rel.Unique(true))
+		// scopes: listo de rajtoj, apartigitaj per komoj
+		t.String("scopes", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("expires_at")
+		t.DateTime("last_used_at")
+
+		t.ForeignKey("user", "users", "id", rel.OnDelete("cascade"))
+	})
+
+	schema.CreateIndex("api_tokens", "api_tokens_user", []string{"user"})
+}
+
+func RollbackAPITokens(schema *rel.Schema) {
+	schema.DropTable("api_tokens")
+}
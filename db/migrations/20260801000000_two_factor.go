@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateTwoFactor(schema *rel.Schema) {
+	// users.totp_secret: sekreto por TOTP; ĝi validas nur post totp_enabled_at
+	schema.AddColumn("users", "totp_secret", rel.String, rel.Required(true), rel.Default(""))
+	schema.AddColumn("users", "totp_enabled_at", rel.DateTime)
+	// users.totp_last_step: la lasta akceptita tempopaŝo, por ke neniu kodo validu dufoje
+	schema.AddColumn("users", "totp_last_step", rel.BigInt, rel.Required(true), rel.Default(0))
+
+	// recovery_codes: unufojaj kodoj por kiam la aparato perdiĝis; nur haŝoj
+	schema.CreateTable("recovery_codes", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("user", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+
+		t.ForeignKey("user", "users", "id", rel.OnDelete("cascade"))
+	})
+
+	schema.CreateIndex("recovery_codes", "recovery_codes_user", []string{"user"})
+
+	// sessions.pending: seanco, kiu ankoraŭ atendas la duan faktoron
+	schema.AddColumn("sessions", "pending", rel.Bool, rel.Required(true), rel.Default(false))
+}
+
+func RollbackTwoFactor(schema *rel.Schema) {
+	schema.DropColumn("sessions", "pending")
+
+	schema.DropTable("recovery_codes")
+
+	schema.DropColumn("users", "totp_last_step")
+	schema.DropColumn("users", "totp_enabled_at")
+	schema.DropColumn("users", "totp_secret")
+}
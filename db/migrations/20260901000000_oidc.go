@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateOIDC(schema *rel.Schema) {
+	// oidc_logins: ensalutoj ĉe OIDC-provizanto, kiuj ankoraŭ ne revenis
+	schema.CreateTable("oidc_logins", func(t *rel.Table) {
+		t.String("id", rel.Primary(true))
+		t.String("nonce", rel.Required(true))
+		t.String("verifier", rel.Required(true))
+
+		t.DateTime("created_at", rel.Required(true))
+		t.DateTime("expires_at", rel.Required(true))
+	})
+}
+
+func RollbackOIDC(schema *rel.Schema) {
+	schema.DropTable("oidc_logins")
+}
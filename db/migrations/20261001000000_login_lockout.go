@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateLoginLockout(schema *rel.Schema) {
+	// users.failed_logins: malĝustaj pasvortoj sinsekve, ĝis la konto ŝlosiĝas
+	schema.AddColumn("users", "failed_logins", rel.Int, rel.Required(true), rel.Default(0))
+	// users.locked_until: ĝis kiam la konto estas ŝlosita
+	schema.AddColumn("users", "locked_until", rel.DateTime)
+}
+
+func RollbackLoginLockout(schema *rel.Schema) {
+	schema.DropColumn("users", "locked_until")
+	schema.DropColumn("users", "failed_logins")
+}
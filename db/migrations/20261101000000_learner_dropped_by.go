@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"github.com/go-rel/rel"
+)
+
+func MigrateLearnerDroppedBy(schema *rel.Schema) {
+	// learners.dropped_by: kiu elprenis la lernanton el la kurso, ĉu li mem aŭ alia
+	schema.AddColumn("learners", "dropped_by", rel.String)
+}
+
+func RollbackLearnerDroppedBy(schema *rel.Schema) {
+	schema.DropColumn("learners", "dropped_by")
+}
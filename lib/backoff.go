@@ -0,0 +1,109 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+// backoffSweepSize is how many keys there can be before quiet ones are forgotten.
+const backoffSweepSize = 10000
+
+// Backoff counts failures by key, such as an IP address, and says how long to wait before
+// trying again. After some free failures, the wait doubles with each failure, up to a most.
+// It is only in memory, so it starts again with the server.
+type Backoff struct {
+	free int
+	base time.Duration
+	max  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*backoffEntry
+}
+
+type backoffEntry struct {
+	failures int
+	last     time.Time
+	until    time.Time
+}
+
+func NewBackoff(free int, base, max time.Duration) *Backoff {
+	return &Backoff{
+		free:    free,
+		base:    base,
+		max:     max,
+		entries: map[string]*backoffEntry{},
+	}
+}
+
+// BackoffDelay is the wait after some failures in a row.
+func BackoffDelay(failures, free int, base, max time.Duration) time.Duration {
+	n := failures - free
+	if n <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 1; i < n; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	return min(delay, max)
+}
+
+// Wait says how much longer a key must wait, or 0 if it may try now.
+func (b *Backoff) Wait(key string, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.entries[key]
+	if !ok || !now.Before(e.until) {
+		return 0
+	}
+
+	return e.until.Sub(now)
+}
+
+// Fail counts a failure for a key, and says how long it must wait before trying again.
+func (b *Backoff) Fail(key string, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.entries) >= backoffSweepSize {
+		b.sweep(now)
+	}
+
+	e, ok := b.entries[key]
+	if !ok || now.Sub(e.last) > b.max {
+		// quiet for long enough to start again
+		e = &backoffEntry{}
+		b.entries[key] = e
+	}
+
+	e.failures++
+	e.last = now
+
+	delay := BackoffDelay(e.failures, b.free, b.base, b.max)
+	e.until = now.Add(delay)
+
+	return delay
+}
+
+// Reset forgets failures of a key, as after a success.
+func (b *Backoff) Reset(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.entries, key)
+}
+
+// sweep forgets keys that have been quiet for longer than the longest wait.
+func (b *Backoff) sweep(now time.Time) {
+	for key, e := range b.entries {
+		if now.Sub(e.last) > b.max && !now.Before(e.until) {
+			delete(b.entries, key)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), BackoffDelay(3, 3, time.Second, time.Minute))
+	assert.Equal(t, time.Second, BackoffDelay(4, 3, time.Second, time.Minute))
+	assert.Equal(t, 2*time.Second, BackoffDelay(5, 3, time.Second, time.Minute))
+	assert.Equal(t, 32*time.Second, BackoffDelay(9, 3, time.Second, time.Minute))
+	assert.Equal(t, time.Minute, BackoffDelay(10, 3, time.Second, time.Minute))
+	assert.Equal(t, time.Minute, BackoffDelay(1000, 3, time.Second, time.Minute))
+}
+
+func TestBackoff(t *testing.T) {
+	b := NewBackoff(1, time.Second, time.Minute)
+
+	now := time.Now()
+
+	assert.Equal(t, time.Duration(0), b.Fail("a", now))
+	assert.Equal(t, time.Duration(0), b.Wait("a", now))
+
+	assert.Equal(t, time.Second, b.Fail("a", now))
+	assert.Equal(t, time.Second, b.Wait("a", now))
+	assert.Equal(t, time.Duration(0), b.Wait("a", now.Add(time.Second)))
+	assert.Equal(t, time.Duration(0), b.Wait("b", now))
+
+	assert.Equal(t, 2*time.Second, b.Fail("a", now.Add(time.Second)))
+
+	// quiet for a long time, so it starts again
+	assert.Equal(t, time.Duration(0), b.Fail("a", now.Add(time.Hour)))
+
+	b.Fail("a", now)
+	b.Reset("a")
+	assert.Equal(t, time.Duration(0), b.Wait("a", now))
+}
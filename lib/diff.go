@@ -0,0 +1,124 @@
+package lib
+
+import (
+	"slices"
+	"strings"
+)
+
+// DiffOp says what happened to a piece of text.
+type DiffOp string
+
+const (
+	DiffSame   DiffOp = "="
+	DiffInsert DiffOp = "+"
+	DiffDelete DiffOp = "-"
+)
+
+type DiffPart struct {
+	Op   DiffOp
+	Text string
+}
+
+// diffMaxCells limits the size of the table that DiffWords fills in, and so its time and memory.
+const diffMaxCells = 1 << 22
+
+// DiffWords compares two texts word by word, so that a changed letter shows as a changed
+// word. Spaces and punctuation count as words of their own. Whatever is the same at the start
+// and end is left out of the comparison, and if the rest is still too much to compare, it
+// shows as all deleted and all inserted.
+func DiffWords(a, b string) []DiffPart {
+	wa, wb := splitWords(a), splitWords(b)
+
+	var head, tail []DiffPart
+
+	n := 0
+	for n < len(wa) && n < len(wb) && wa[n] == wb[n] {
+		n++
+	}
+
+	if n > 0 {
+		head = []DiffPart{{Op: DiffSame, Text: strings.Join(wa[:n], "")}}
+		wa, wb = wa[n:], wb[n:]
+	}
+
+	n = 0
+	for n < len(wa) && n < len(wb) && wa[len(wa)-1-n] == wb[len(wb)-1-n] {
+		n++
+	}
+
+	if n > 0 {
+		tail = []DiffPart{{Op: DiffSame, Text: strings.Join(wa[len(wa)-n:], "")}}
+		wa, wb = wa[:len(wa)-n], wb[:len(wb)-n]
+	}
+
+	var middle []DiffPart
+
+	if (len(wa)+1)*(len(wb)+1) > diffMaxCells {
+		middle = []DiffPart{
+			{Op: DiffDelete, Text: strings.Join(wa, "")},
+			{Op: DiffInsert, Text: strings.Join(wb, "")},
+		}
+		middle = slices.DeleteFunc(middle, func(p DiffPart) bool { return p.Text == "" })
+	} else {
+		middle = diffWordsLCS(wa, wb)
+	}
+
+	return slices.Concat(head, middle, tail)
+}
+
+// diffWordsLCS compares words with a longest common subsequence table.
+func diffWordsLCS(wa, wb []string) []DiffPart {
+	// lcs[i][j] is the longest common subsequence of wa[i:] and wb[j:]
+	lcs := make([][]int, len(wa)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(wb)+1)
+	}
+
+	for i := len(wa) - 1; i >= 0; i-- {
+		for j := len(wb) - 1; j >= 0; j-- {
+			if wa[i] == wb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []DiffPart
+
+	add := func(op DiffOp, text string) {
+		if n := len(out); n > 0 && out[n-1].Op == op {
+			out[n-1].Text += text
+			return
+		}
+
+		out = append(out, DiffPart{Op: op, Text: text})
+	}
+
+	i, j := 0, 0
+
+	for i < len(wa) && j < len(wb) {
+		switch {
+		case wa[i] == wb[j]:
+			add(DiffSame, wa[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(DiffDelete, wa[i])
+			i++
+		default:
+			add(DiffInsert, wb[j])
+			j++
+		}
+	}
+
+	for ; i < len(wa); i++ {
+		add(DiffDelete, wa[i])
+	}
+
+	for ; j < len(wb); j++ {
+		add(DiffInsert, wb[j])
+	}
+
+	return out
+}
@@ -0,0 +1,43 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffWords(t *testing.T) {
+	parts := DiffWords("Mi ŝatas manĝi pomoj.", "Mi ŝatas manĝi pomojn!")
+
+	assert.Equal(t, []DiffPart{
+		{DiffSame, "Mi ŝatas manĝi "},
+		{DiffDelete, "pomoj."},
+		{DiffInsert, "pomojn!"},
+	}, parts)
+}
+
+func TestDiffWordsEmpty(t *testing.T) {
+	assert.Equal(t, []DiffPart{{DiffInsert, "saluton"}}, DiffWords("", "saluton"))
+	assert.Nil(t, DiffWords("", ""))
+}
+
+func TestDiffWordsLarge(t *testing.T) {
+	a := strings.Repeat("unu du ", 2000) + "unu"
+	b := strings.Repeat("tri kvar ", 2000) + "tri"
+
+	parts := DiffWords("Komenco. "+a+" Fino.", "Komenco. "+b+" Fino.")
+
+	if assert.Len(t, parts, 4) {
+		assert.Equal(t, DiffPart{DiffSame, "Komenco. "}, parts[0])
+		assert.True(t, parts[1].Op == DiffDelete && parts[1].Text == a)
+		assert.True(t, parts[2].Op == DiffInsert && parts[2].Text == b)
+		assert.Equal(t, DiffPart{DiffSame, " Fino."}, parts[3])
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	assert.Equal(t, 0, CountWords(""))
+	assert.Equal(t, 4, CountWords("Mi ŝatas manĝi pomojn!"))
+	assert.Equal(t, 3, CountWords("  unu, du - tri  "))
+}
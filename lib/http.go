@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 )
@@ -38,6 +39,8 @@ var ErrHTTPUnauthorized = newHTTPError(http.StatusUnauthorized)
 var ErrHTTPForbidden = newHTTPError(http.StatusForbidden)
 var ErrHTTPNotFound = newHTTPError(http.StatusNotFound)
 var ErrHTTPConflict = newHTTPError(http.StatusConflict)
+var ErrHTTPTooLarge = newHTTPError(http.StatusRequestEntityTooLarge)
+var ErrHTTPUnsupportedMedia = newHTTPError(http.StatusUnsupportedMediaType)
 var ErrHTTPInternal = newHTTPError(http.StatusInternalServerError)
 
 func safeCall(f func()) any {
@@ -108,7 +111,10 @@ func BasicMiddleware(recover bool) MiddlewareFunc {
 type HTTPResponse struct {
 	Status  int
 	Cookies []*http.Cookie
+	Header  http.Header
 	Data    any
+	// Body, if set, is sent as it is, instead of Data as JSON.
+	Body io.Reader
 }
 
 type APIFunc func(ctx context.Context, r *http.Request) any
@@ -156,22 +162,45 @@ func SendHTTPError(w http.ResponseWriter, status int, err error) {
 }
 
 func SendHTTPResponse(w http.ResponseWriter, res HTTPResponse) {
-	data, err := json.Marshal(res.Data)
-	if err != nil {
-		w.WriteHeader(500)
-		w.Write([]byte("output error: " + err.Error()))
-		return
+	var data []byte
+
+	if res.Body == nil {
+		var err error
+
+		data, err = json.Marshal(res.Data)
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte("output error: " + err.Error()))
+			return
+		}
 	}
 
 	for _, c := range res.Cookies {
 		http.SetCookie(w, c)
 	}
 
+	for k, vs := range res.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+
 	status := res.Status
 	if status == 0 {
 		status = http.StatusOK
 	}
 
 	w.WriteHeader(status)
+
+	if res.Body != nil {
+		io.Copy(w, res.Body)
+
+		if c, ok := res.Body.(io.Closer); ok {
+			c.Close()
+		}
+
+		return
+	}
+
 	w.Write(data)
 }
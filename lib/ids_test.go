@@ -11,3 +11,24 @@ func TestMakeRandomID(t *testing.T) {
 
 	assert.Len(t, r, 7)
 }
+
+func TestMakeSecretToken(t *testing.T) {
+	a, err := MakeSecretToken("abc", 32)
+	assert.NoError(t, err)
+	assert.Len(t, a, 47)
+
+	b, err := MakeSecretToken("abc", 32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, b)
+
+	assert.Equal(t, HashToken(a), HashToken(a))
+	assert.NotEqual(t, HashToken(a), HashToken(b))
+}
+
+func TestMakeSecretCode(t *testing.T) {
+	a, err := MakeSecretCode(8)
+	assert.NoError(t, err)
+	assert.Len(t, a, 8)
+	assert.NotContains(t, a, "0")
+	assert.NotContains(t, a, "O")
+}
@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer accepts one connection, and sends back what was delivered. It offers no
+// extensions, so there is no STARTTLS or login.
+func fakeSMTPServer(t *testing.T) (string, <-chan []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { l.Close() })
+
+	out := make(chan []string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+		var got []string
+
+		reply("220 fake")
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			verb, _, _ := strings.Cut(line, " ")
+
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				reply("250 fake")
+			case "MAIL", "RCPT":
+				got = append(got, line)
+				reply("250 ok")
+			case "DATA":
+				reply("354 go on")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					line = strings.TrimRight(line, "\r\n")
+					if line == "." {
+						break
+					}
+					got = append(got, line)
+				}
+				reply("250 ok")
+			case "QUIT":
+				reply("221 bye")
+				out <- got
+				return
+			default:
+				reply("502 no")
+			}
+		}
+	}()
+
+	return l.Addr().String(), out
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, delivered := fakeSMTPServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := NewSMTPSender(addr, "", "", "skribserv@example.org")
+
+	err := s.SendMail(ctx, "lernanto@example.org", "Ĉu vi?", "saluton\nĝis")
+	assert.NoError(t, err)
+
+	got := <-delivered
+
+	assert.Contains(t, got, "MAIL FROM:<skribserv@example.org>")
+	assert.Contains(t, got, "RCPT TO:<lernanto@example.org>")
+	assert.Contains(t, got, "To: lernanto@example.org")
+	assert.Contains(t, got, "Subject: =?utf-8?q?=C4=88u_vi=3F?=")
+	assert.Contains(t, got, "saluton")
+	assert.Contains(t, got, "ĝis")
+}
@@ -0,0 +1,172 @@
+package lib
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(
+		parser.WithASTTransformers(util.Prioritized(esperantoTransformer{}, 100)),
+	),
+	goldmark.WithRendererOptions(
+		renderer.WithNodeRenderers(util.Prioritized(vocabularyRenderer{}, 100)),
+	),
+)
+
+var markdownPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^vortaro$`)).OnElements("table")
+	return p
+}()
+
+// RenderMarkdown makes safe HTML from Markdown. Text in x-system is converted, so "cxu"
+// becomes "ĉu", except in code. A fenced block marked "vortaro", with lines like
+// "vorto = signifo", becomes a vocabulary table.
+func RenderMarkdown(source string) (string, error) {
+	var buf bytes.Buffer
+
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return markdownPolicy.Sanitize(buf.String()), nil
+}
+
+var kindVocabulary = ast.NewNodeKind("Vocabulary")
+
+// vocabularyNode is a table of words and what they mean.
+type vocabularyNode struct {
+	ast.BaseBlock
+	rows [][2]string
+}
+
+func (n *vocabularyNode) Kind() ast.NodeKind {
+	return kindVocabulary
+}
+
+func (n *vocabularyNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// esperantoTransformer converts x-system text and finds vocabulary blocks.
+type esperantoTransformer struct{}
+
+func (esperantoTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+
+	var texts []*ast.Text
+	var blocks []*ast.FencedCodeBlock
+
+	// the tree can't be changed while walking it
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.CodeSpan:
+			return ast.WalkSkipChildren, nil
+		case *ast.FencedCodeBlock:
+			if string(n.Language(source)) == "vortaro" {
+				blocks = append(blocks, n)
+			}
+		case *ast.Text:
+			texts = append(texts, n)
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	for _, t := range texts {
+		convertText(t, source)
+	}
+
+	for _, b := range blocks {
+		table := &vocabularyNode{}
+
+		lines := b.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			segment := lines.At(i)
+			line := strings.TrimSpace(string(segment.Value(source)))
+			if line == "" {
+				continue
+			}
+
+			word, meaning, _ := strings.Cut(line, "=")
+			table.rows = append(table.rows, [2]string{
+				FromXSystem(strings.TrimSpace(word)),
+				FromXSystem(strings.TrimSpace(meaning)),
+			})
+		}
+
+		b.Parent().ReplaceChild(b.Parent(), b, table)
+	}
+}
+
+// convertText replaces a text node with its x-system conversion, if that changes anything.
+func convertText(t *ast.Text, source []byte) {
+	value := string(t.Segment.Value(source))
+
+	converted := FromXSystem(value)
+	if converted == value {
+		return
+	}
+
+	if t.SoftLineBreak() {
+		converted += "\n"
+	}
+
+	s := ast.NewString([]byte(converted))
+	s.SetRaw(t.IsRaw())
+
+	parent := t.Parent()
+	parent.InsertBefore(parent, t, s)
+
+	if t.HardLineBreak() {
+		// keep an empty text, just for the line break
+		t.Segment = t.Segment.WithStop(t.Segment.Start)
+		t.SetSoftLineBreak(false)
+		return
+	}
+
+	parent.RemoveChild(parent, t)
+}
+
+// vocabularyRenderer writes vocabulary tables as HTML.
+type vocabularyRenderer struct{}
+
+func (vocabularyRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindVocabulary, renderVocabulary)
+}
+
+func renderVocabulary(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	_, _ = w.WriteString("<table class=\"vortaro\">\n<thead>\n<tr><th>vorto</th><th>signifo</th></tr>\n</thead>\n<tbody>\n")
+
+	for _, row := range n.(*vocabularyNode).rows {
+		_, _ = w.WriteString("<tr><td>")
+		_, _ = w.Write(util.EscapeHTML([]byte(row[0])))
+		_, _ = w.WriteString("</td><td>")
+		_, _ = w.Write(util.EscapeHTML([]byte(row[1])))
+		_, _ = w.WriteString("</td></tr>\n")
+	}
+
+	_, _ = w.WriteString("</tbody>\n</table>\n")
+
+	return ast.WalkSkipChildren, nil
+}
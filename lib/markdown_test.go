@@ -0,0 +1,32 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromXSystem(t *testing.T) {
+	assert.Equal(t, "ĉu vi ŝatas ĝin?", FromXSystem("cxu vi sxatas gxin?"))
+	assert.Equal(t, "Ĉiuj Ŭ", FromXSystem("CXiuj UX"))
+	assert.Equal(t, "Linux", FromXSystem("Linuxx"))
+	assert.Equal(t, "xilofono", FromXSystem("xilofono"))
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	html, err := RenderMarkdown("# Saluton\n\nCxu vi `cxu`?\n\n<script>alert(1)</script>\n\n```vortaro\nhundo = dog\nkato = cat\n```\n")
+	assert.NoError(t, err)
+
+	assert.Contains(t, html, "<h1")
+	assert.Contains(t, html, "Ĉu vi <code>cxu</code>?")
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, `<table class="vortaro">`)
+	assert.Contains(t, html, "<td>hundo</td><td>dog</td>")
+}
+
+func TestRenderMarkdownLineBreaks(t *testing.T) {
+	html, err := RenderMarkdown("unu cxi  \ndu gxi\ntri")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p>unu ĉi<br>\ndu ĝi\ntri</p>\n", html)
+}
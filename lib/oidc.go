@@ -0,0 +1,443 @@
+package lib
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oidcLeeway allows for clocks that are not quite the same.
+	oidcLeeway = time.Minute
+	// oidcKeysMinAge stops unknown key IDs from making the keys be fetched again and again.
+	oidcKeysMinAge = time.Minute
+)
+
+// ErrOIDC is for ID tokens and answers from the provider that can't be trusted.
+var ErrOIDC = errors.New("oidc")
+
+// OIDCProvider is an OpenID Connect provider, for a relying party that uses the authorization
+// code flow with PKCE. Everything about the provider is found by discovery from the issuer,
+// the first time it is needed. Only RS256 ID tokens are accepted.
+type OIDCProvider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	mu     sync.Mutex
+	meta   *oidcMetadata
+	keys   map[string]*rsa.PublicKey
+	keysAt time.Time
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCClaims are the claims of an ID token that matter here.
+type OIDCClaims struct {
+	Issuer          string       `json:"iss"`
+	Subject         string       `json:"sub"`
+	Audience        oidcAudience `json:"aud"`
+	AuthorizedParty string       `json:"azp"`
+	Expiry          int64        `json:"exp"`
+	IssuedAt        int64        `json:"iat"`
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   bool         `json:"email_verified"`
+	Name            string       `json:"name"`
+}
+
+// oidcAudience can be one string or many.
+type oidcAudience []string
+
+func (a *oidcAudience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = oidcAudience{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+
+	*a = many
+
+	return nil
+}
+
+// NewOIDCProvider makes a provider, without talking to it yet. Without a client secret, the
+// client is public, and only PKCE protects the code.
+func NewOIDCProvider(issuer, clientID, clientSecret, redirectURL string, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OIDCProvider{
+		issuer:       strings.TrimRight(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       client,
+	}
+}
+
+// MakePKCEVerifier makes a random code verifier, as in RFC 7636.
+func MakePKCEVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCEChallenge is the S256 challenge for a verifier.
+func PKCEChallenge(verifier string) string {
+	h := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// AuthCodeURL is where to send the user to log in at the provider.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	v := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {PKCEChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return meta.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+// Exchange swaps a code from the provider for an ID token, which is not yet verified.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {verifier},
+	}
+
+	if p.clientSecret == "" {
+		form.Set("client_id", p.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("oidc token: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	var res struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	status, err := p.fetchJSON(req, &res)
+	if err != nil {
+		return "", fmt.Errorf("oidc token: %w", err)
+	}
+
+	if status != http.StatusOK || res.Error != "" {
+		return "", fmt.Errorf("%w: token: %d %s %s", ErrOIDC, status, res.Error, res.ErrorDescription)
+	}
+
+	if res.IDToken == "" {
+		return "", fmt.Errorf("%w: token: no id_token", ErrOIDC)
+	}
+
+	return res.IDToken, nil
+}
+
+// VerifyIDToken checks the signature and claims of an ID token, including that it was made
+// for this login, by the nonce.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, raw, nonce string, now time.Time) (OIDCClaims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return OIDCClaims{}, fmt.Errorf("%w: id token: not a jwt", ErrOIDC)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: id token: header: %w", ErrOIDC, err)
+	}
+
+	if header.Alg != "RS256" {
+		return OIDCClaims{}, fmt.Errorf("%w: id token: alg %q", ErrOIDC, header.Alg)
+	}
+
+	key, err := p.key(ctx, meta, header.Kid)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: id token: signature: %w", ErrOIDC, err)
+	}
+
+	h := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, h[:], sig); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: id token: signature: %w", ErrOIDC, err)
+	}
+
+	var claims OIDCClaims
+
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: id token: claims: %w", ErrOIDC, err)
+	}
+
+	if err := p.checkClaims(claims, meta, nonce, now); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: id token: %w", ErrOIDC, err)
+	}
+
+	return claims, nil
+}
+
+func (p *OIDCProvider) checkClaims(claims OIDCClaims, meta *oidcMetadata, nonce string, now time.Time) error {
+	if claims.Issuer != meta.Issuer {
+		return fmt.Errorf("issuer %q", claims.Issuer)
+	}
+
+	if !slices.Contains(claims.Audience, p.clientID) {
+		return errors.New("not for this client")
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientID {
+		return errors.New("not for this client")
+	}
+
+	if now.After(time.Unix(claims.Expiry, 0).Add(oidcLeeway)) {
+		return errors.New("expired")
+	}
+
+	if now.Add(oidcLeeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return errors.New("issued in the future")
+	}
+
+	if claims.Nonce == "" || claims.Nonce != nonce {
+		return errors.New("wrong nonce")
+	}
+
+	if claims.Subject == "" {
+		return errors.New("no subject")
+	}
+
+	return nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// discover gets the provider metadata, once.
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	var meta oidcMetadata
+
+	status, err := p.fetchJSON(req, &meta)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery: status %d", ErrOIDC, status)
+	}
+
+	// anyone else could claim to be the issuer, as in OpenID Connect Discovery 4.3
+	if meta.Issuer != p.issuer {
+		return nil, fmt.Errorf("%w: discovery: issuer %q", ErrOIDC, meta.Issuer)
+	}
+
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery: missing endpoints", ErrOIDC)
+	}
+
+	p.meta = &meta
+
+	return p.meta, nil
+}
+
+// key finds a signing key of the provider. Keys are fetched again when an unknown key ID
+// turns up, since providers change keys from time to time.
+func (p *OIDCProvider) key(ctx context.Context, meta *oidcMetadata, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+
+	if time.Since(p.keysAt) < oidcKeysMinAge {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrOIDC, kid)
+	}
+
+	keys, err := p.fetchKeys(ctx, meta.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	p.keys = keys
+	p.keysAt = time.Now()
+
+	if key := p.findKey(kid); key != nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown key %q", ErrOIDC, kid)
+}
+
+// findKey finds a key by ID. Without an ID, there has to be only one key.
+func (p *OIDCProvider) findKey(kid string) *rsa.PublicKey {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+
+	return p.keys[kid]
+}
+
+func (p *OIDCProvider) fetchKeys(ctx context.Context, uri string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("oidc keys: %w", err)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+
+	status, err := p.fetchJSON(req, &jwks)
+	if err != nil {
+		return nil, fmt.Errorf("oidc keys: %w", err)
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: keys: status %d", ErrOIDC, status)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) > 4 {
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+// fetchJSON does a request, and decodes any JSON answer, whatever the status.
+func (p *OIDCProvider) fetchJSON(req *http.Request, v any) (int, error) {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil && res.StatusCode == http.StatusOK {
+		return 0, err
+	}
+
+	return res.StatusCode, nil
+}
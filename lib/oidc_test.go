@@ -0,0 +1,240 @@
+package lib
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOIDCIssuer is enough of a provider to log in against. Codes are made by login, as if a
+// user had logged in at the provider.
+type fakeOIDCIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]url.Values
+	// claims are changed, for tests of bad tokens
+	claims func(map[string]any)
+}
+
+func newFakeOIDCIssuer(t *testing.T) *fakeOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeOIDCIssuer{key: key, codes: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/auth",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/keys",
+		})
+	})
+
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]any{{
+				"kty": "RSA",
+				"use": "sig",
+				"kid": "k1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		auth, ok := f.codes[r.FormValue("code")]
+		delete(f.codes, r.FormValue("code"))
+		f.mu.Unlock()
+
+		id, secret, _ := r.BasicAuth()
+
+		if !ok || id != "klient" || secret != "sekret" ||
+			PKCEChallenge(r.FormValue("code_verifier")) != auth.Get("code_challenge") ||
+			r.FormValue("redirect_uri") != auth.Get("redirect_uri") {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token_type": "Bearer",
+			"id_token":   f.sign(t, auth),
+		})
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeOIDCIssuer) login(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := MakeRandomID("code", 8)
+
+	f.mu.Lock()
+	f.codes[code] = u.Query()
+	f.mu.Unlock()
+
+	return code
+}
+
+func (f *fakeOIDCIssuer) sign(t *testing.T, auth url.Values) string {
+	now := time.Now()
+
+	claims := map[string]any{
+		"iss":            f.URL,
+		"sub":            "123",
+		"aud":            auth.Get("client_id"),
+		"exp":            now.Add(time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          auth.Get("nonce"),
+		"email":          "ludoviko@example.com",
+		"email_verified": true,
+		"name":           "Ludoviko",
+	}
+
+	if f.claims != nil {
+		f.claims(claims)
+	}
+
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	signed := enc(map[string]any{"alg": "RS256", "kid": "k1", "typ": "JWT"}) + "." + enc(claims)
+
+	h := sha256.Sum256([]byte(signed))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDCProvider(t *testing.T) {
+	ctx := context.Background()
+
+	issuer := newFakeOIDCIssuer(t)
+
+	p := NewOIDCProvider(issuer.URL, "klient", "sekret", "http://localhost/reveno", nil)
+
+	flow := func(nonce string) (OIDCClaims, error) {
+		verifier, err := MakePKCEVerifier()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		authURL, err := p.AuthCodeURL(ctx, "stato", "nonco", verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, strings.HasPrefix(authURL, issuer.URL+"/auth?"))
+
+		code := issuer.login(t, authURL)
+
+		raw, err := p.Exchange(ctx, code, verifier)
+		if err != nil {
+			return OIDCClaims{}, err
+		}
+
+		return p.VerifyIDToken(ctx, raw, nonce, time.Now())
+	}
+
+	claims, err := flow("nonco")
+	assert.NoError(t, err)
+	assert.Equal(t, "123", claims.Subject)
+	assert.Equal(t, "ludoviko@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+
+	_, err = flow("alia")
+	assert.ErrorIs(t, err, ErrOIDC)
+
+	issuer.claims = func(c map[string]any) { c["aud"] = []string{"klient", "alia"} }
+	_, err = flow("nonco")
+	assert.ErrorIs(t, err, ErrOIDC)
+
+	issuer.claims = func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }
+	_, err = flow("nonco")
+	assert.ErrorIs(t, err, ErrOIDC)
+
+	issuer.claims = func(c map[string]any) { c["iss"] = "https://alia.example.com" }
+	_, err = flow("nonco")
+	assert.ErrorIs(t, err, ErrOIDC)
+
+	issuer.claims = nil
+
+	// a code can't be used without the verifier it was made for
+	authURL, err := p.AuthCodeURL(ctx, "stato", "nonco", "malĝusta")
+	assert.NoError(t, err)
+	_, err = p.Exchange(ctx, issuer.login(t, authURL), "alia")
+	assert.ErrorIs(t, err, ErrOIDC)
+}
+
+func TestOIDCProviderWrongIssuer(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+
+	p := NewOIDCProvider(issuer.URL+"/alia", "klient", "sekret", "http://localhost/reveno", nil)
+
+	_, err := p.AuthCodeURL(context.Background(), "stato", "nonco", "v")
+	assert.Error(t, err)
+}
+
+func TestOIDCProviderTamperedToken(t *testing.T) {
+	ctx := context.Background()
+
+	issuer := newFakeOIDCIssuer(t)
+
+	p := NewOIDCProvider(issuer.URL, "klient", "sekret", "http://localhost/reveno", nil)
+
+	raw := issuer.sign(t, url.Values{"client_id": {"klient"}, "nonce": {"nonco"}})
+
+	_, err := p.VerifyIDToken(ctx, raw, "nonco", time.Now())
+	assert.NoError(t, err)
+
+	parts := strings.Split(raw, ".")
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	claims = []byte(strings.Replace(string(claims), "ludoviko@", "admin@", 1))
+	parts[1] = base64.RawURLEncoding.EncodeToString(claims)
+
+	_, err = p.VerifyIDToken(ctx, strings.Join(parts, "."), "nonco", time.Now())
+	assert.ErrorIs(t, err, ErrOIDC)
+
+	_, err = p.VerifyIDToken(ctx, fmt.Sprintf("%s.%s.", parts[0], parts[1]), "nonco", time.Now())
+	assert.ErrorIs(t, err, ErrOIDC)
+}
@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters, roughly the OWASP recommendation for interactive logins.
+const (
+	argonTime    = 2
+	argonMemory  = 19 * 1024
+	argonThreads = 1
+	argonSaltLen = 16
+	argonKeyLen  = 32
+)
+
+const argonPrefix = "$argon2id$"
+
+var ErrBadPasswordHash = errors.New("bad password hash")
+
+// HashPassword makes an argon2id hash of a password, encoded in the usual PHC string format.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+
+	enc := base64.RawStdEncoding
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argonPrefix, argon2.Version, argonMemory, argonTime, argonThreads, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// IsPasswordHash says whether a stored value looks like something made by [HashPassword].
+func IsPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, argonPrefix)
+}
+
+// CheckPassword compares a password to a stored value in constant time. The stored value
+// may be a legacy plaintext password, in which case, or if the hash parameters are out of
+// date, rehash will be true, and the caller should store a new hash.
+func CheckPassword(stored, password string) (ok bool, rehash bool) {
+	if !IsPasswordHash(stored) {
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return ok, true
+	}
+
+	p, salt, key, err := decodePasswordHash(stored)
+	if err != nil {
+		return false, false
+	}
+
+	key1 := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+
+	ok = subtle.ConstantTimeCompare(key, key1) == 1
+
+	rehash = p.time != argonTime || p.memory != argonMemory || p.threads != argonThreads || len(key) != argonKeyLen
+
+	return ok, rehash
+}
+
+type argonParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+func decodePasswordHash(stored string) (argonParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return argonParams{}, nil, nil, ErrBadPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argonParams{}, nil, nil, ErrBadPasswordHash
+	}
+
+	var p argonParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return argonParams{}, nil, nil, ErrBadPasswordHash
+	}
+
+	enc := base64.RawStdEncoding
+
+	salt, err := enc.DecodeString(parts[4])
+	if err != nil {
+		return argonParams{}, nil, nil, ErrBadPasswordHash
+	}
+
+	key, err := enc.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argonParams{}, nil, nil, ErrBadPasswordHash
+	}
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,29 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashPassword(t *testing.T) {
+	h, err := HashPassword("sekreto")
+	assert.NoError(t, err)
+	assert.True(t, IsPasswordHash(h))
+
+	ok, rehash := CheckPassword(h, "sekreto")
+	assert.True(t, ok)
+	assert.False(t, rehash)
+
+	ok, _ = CheckPassword(h, "malĝusta")
+	assert.False(t, ok)
+}
+
+func TestCheckPasswordPlaintext(t *testing.T) {
+	ok, rehash := CheckPassword("sekreto", "sekreto")
+	assert.True(t, ok)
+	assert.True(t, rehash)
+
+	ok, _ = CheckPassword("sekreto", "malĝusta")
+	assert.False(t, ok)
+}
@@ -0,0 +1,35 @@
+package lib
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+
+	n, err := store.PutBlob(ctx, "hejmtaskoj/d-1", strings.NewReader("saluton"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+
+	r, err := store.GetBlob(ctx, "hejmtaskoj/d-1")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "saluton", string(data))
+
+	assert.NoError(t, store.DeleteBlob(ctx, "hejmtaskoj/d-1"))
+
+	_, err = store.GetBlob(ctx, "hejmtaskoj/d-1")
+	assert.ErrorIs(t, err, ErrNoBlob)
+
+	_, err = store.PutBlob(ctx, "../ekstere", strings.NewReader(""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package lib
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// MakeSecretToken makes an unguessable token from n bytes of crypto/rand, with a prefix
+// so that it can be recognised.
+func MakeSecretToken(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return prefix + "-" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken makes a hash of a token for storage. Tokens are random enough already that
+// there is no need for a slow hash or a salt.
+func HashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// symbolsForCodes leave out letters that are easily mixed up, like O and 0.
+const symbolsForCodes = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// MakeSecretCode makes a short unguessable code that people can read out and type in.
+func MakeSecretCode(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = symbolsForCodes[int(b[i])%len(symbolsForCodes)]
+	}
+
+	return string(b), nil
+}
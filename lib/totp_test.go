@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is "12345678901234567890", the SHA1 key of RFC 6238.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// the last 6 digits of the 8 digit codes in the RFC
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, unix)
+	}
+}
+
+func TestCheckTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := CheckTOTP(rfcSecret, "081804", now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now), step)
+
+	// a little late is fine
+	_, ok = CheckTOTP(rfcSecret, "081804", now.Add(30*time.Second))
+	assert.True(t, ok)
+
+	_, ok = CheckTOTP(rfcSecret, "081804", now.Add(5*time.Minute))
+	assert.False(t, ok)
+
+	_, ok = CheckTOTP(rfcSecret, "81804", now)
+	assert.False(t, ok)
+}
+
+func TestMakeTOTPSecret(t *testing.T) {
+	secret, err := MakeTOTPSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	_, err = TOTPCode(secret, 1)
+	assert.NoError(t, err)
+
+	uri := TOTPURI("Skribserv", "lz@example.org", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Skribserv:lz@example.org?"))
+	assert.Contains(t, uri, "secret="+secret)
+}
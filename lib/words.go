@@ -0,0 +1,46 @@
+package lib
+
+import (
+	"unicode"
+)
+
+// CountWords counts runs of letters and digits.
+func CountWords(text string) int {
+	n := 0
+
+	for _, w := range splitWords(text) {
+		if r := []rune(w)[0]; unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// splitWords cuts text into runs of letters and digits, runs of space, and single other runes.
+func splitWords(text string) []string {
+	var out []string
+
+	kind := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	runes := []rune(text)
+	start := 0
+
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || kind(runes[i]) != kind(runes[start]) || kind(runes[start]) == 3 {
+			out = append(out, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"strings"
+	"unicode"
+)
+
+var xLetters = map[rune]rune{
+	'c': 'ĉ', 'g': 'ĝ', 'h': 'ĥ', 'j': 'ĵ', 's': 'ŝ', 'u': 'ŭ',
+	'C': 'Ĉ', 'G': 'Ĝ', 'H': 'Ĥ', 'J': 'Ĵ', 'S': 'Ŝ', 'U': 'Ŭ',
+}
+
+// FromXSystem turns x-system spelling, like "cxu", into proper letters, like "ĉu". A doubled
+// x after one of the letters stands for a real x, so "fluxx" gives "flux".
+func FromXSystem(text string) string {
+	if !strings.ContainsAny(text, "xX") {
+		return text
+	}
+
+	runes := []rune(text)
+
+	var out strings.Builder
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		hat, ok := xLetters[r]
+		if !ok || i+1 >= len(runes) || unicode.ToLower(runes[i+1]) != 'x' {
+			out.WriteRune(r)
+			continue
+		}
+
+		if i+2 < len(runes) && unicode.ToLower(runes[i+2]) == 'x' {
+			out.WriteRune(r)
+			out.WriteRune(runes[i+1])
+			i += 2
+			continue
+		}
+
+		out.WriteRune(hat)
+		i++
+	}
+
+	return out.String()
+}
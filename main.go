@@ -107,7 +107,7 @@ func main() {
 
 	// app
 
-	theApp, err := app.New(db, log.Raw().With("so", "app"))
+	theApp, err := app.New(db, config, log.Raw().With("so", "app"))
 	if err != nil {
 		log.Error("make app", "err", err)
 		os.Exit(1)
@@ -119,6 +119,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	go theApp.Run(ctx)
+
 	theApp.Mount(func(method, path string, handler http.HandlerFunc, mws ...lib.MiddlewareFunc) {
 		for _, m := range mws {
 			handler = m(handler)
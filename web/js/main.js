@@ -1,4 +1,4 @@
-import { PageManagerElement, PageElement, mkel, setCookie, getCookie } from './util.js'
+import { PageManagerElement, PageElement, mkel } from './util.js'
 
 customElements.define('page-manager', class extends PageManagerElement {
   #state
@@ -37,18 +37,14 @@ customElements.define('page-manager', class extends PageManagerElement {
   }
 
   async setup() {
-    if (getCookie('Seanco')) {
-      const res = await fetch('/api/mi')
-
-      if (res.status != 200) {
-        setCookie('Seanco', '', -1)
-        this.swapPage('login-page', true)
-      } else {
-        const json = await res.json()
-        this.onUserReady(json.ento)
-      }
-    } else {
+    // the session cookie is http only, so just ask
+    const res = await fetch('/api/mi')
+
+    if (res.status != 200) {
       this.swapPage('login-page', true)
+    } else {
+      const json = await res.json()
+      this.onUserReady(json.ento)
     }
 
     this.#state.ready = true
@@ -100,7 +96,6 @@ customElements.define('page-manager', class extends PageManagerElement {
     this.shadowRoot.querySelector('.username').textContent = ''
 
     this.resetState()
-    setCookie('Seanco', '', -1)
 
     history.pushState({}, '', '/')
     this.swapPage('login-page', true)